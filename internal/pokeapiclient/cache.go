@@ -0,0 +1,39 @@
+package pokeapiclient
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/mdwiltfong/PokeDex/internal/pokecache"
+)
+
+// Cache is the storage the client keeps responses in. pokecache provides an
+// in-memory Cache (NewCache), a disk-backed one (NewPersistentCache) and
+// NoopCache, which stores nothing.
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	Add(key string, val []byte)
+	// AddWithHeader stores val with its response headers, fresh for ttl
+	// and then stale for staleFor.
+	AddWithHeader(key string, val []byte, header http.Header, ttl, staleFor time.Duration)
+	// Lookup returns fresh and stale entries alike, reporting which it is.
+	Lookup(key string) (val []byte, header http.Header, fresh bool, ok bool)
+	// Refresh marks an existing entry fresh again.
+	Refresh(key string, ttl, staleFor time.Duration) bool
+	Remove(key string) bool
+	Len() int
+	Close() error
+}
+
+// InspectableCache is implemented by caches that can report what they hold.
+type InspectableCache interface {
+	Cache
+	Stats() pokecache.Stats
+	Entries() []pokecache.EntryInfo
+	Clear() int
+}
+
+var (
+	_ InspectableCache = (*pokecache.Cache)(nil)
+	_ Cache            = pokecache.NoopCache{}
+)
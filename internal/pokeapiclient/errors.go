@@ -0,0 +1,72 @@
+package pokeapiclient
+
+import (
+	"fmt"
+	"time"
+)
+
+// NotFoundError is returned when PokeAPI answers with a 404.
+type NotFoundError struct {
+	URL string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s was not found", e.URL)
+}
+
+// UpstreamStatusError is returned for any other non-2xx response.
+// RetryAfter holds the server's Retry-After hint, if it sent one.
+type UpstreamStatusError struct {
+	URL        string
+	Code       int
+	Body       []byte
+	RetryAfter time.Duration
+}
+
+func (e *UpstreamStatusError) Error() string {
+	return fmt.Sprintf("response from %s failed with status code: %d and body: %s", e.URL, e.Code, e.Body)
+}
+
+// DecodeError is returned when a response body is not the JSON we expected.
+type DecodeError struct {
+	URL string
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("failed to decode response from %s: %s", e.URL, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// RequestError is returned when a request could not even be built, for
+// example because the URL is malformed. Retrying would not help.
+type RequestError struct {
+	URL string
+	Err error
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("could not make a request to %s: %s", e.URL, e.Err)
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// NetworkError is returned when the request never produced a response,
+// or the response body could not be read.
+type NetworkError struct {
+	URL string
+	Err error
+}
+
+func (e *NetworkError) Error() string {
+	return fmt.Sprintf("there was an issue with the API request to %s: %s", e.URL, e.Err)
+}
+
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
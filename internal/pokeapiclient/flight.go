@@ -0,0 +1,42 @@
+package pokeapiclient
+
+import "sync"
+
+// flightGroup collapses concurrent calls for the same key into one, in the
+// spirit of golang.org/x/sync/singleflight.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flight
+}
+
+type flight struct {
+	wg   sync.WaitGroup
+	body []byte
+	err  error
+}
+
+// do runs fn for key unless a call for key is already in flight, in which
+// case it waits for that call and returns its result instead.
+func (g *flightGroup) do(key string, fn func() ([]byte, error)) ([]byte, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flight)
+	}
+	if f, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		f.wg.Wait()
+		return f.body, f.err
+	}
+	f := &flight{}
+	f.wg.Add(1)
+	g.calls[key] = f
+	g.mu.Unlock()
+
+	f.body, f.err = fn()
+	f.wg.Done()
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+	return f.body, f.err
+}
@@ -1,20 +1,358 @@
 package pokeapiclient
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/mdwiltfong/PokeDex/internal/pokecache"
 )
 
+// DefaultBaseURL is the public PokeAPI the client talks to unless told otherwise.
+const DefaultBaseURL = "https://pokeapi.co/api/v2"
+
+// BaseURLEnv names the environment variable that overrides DefaultBaseURL.
+const BaseURLEnv = "POKEAPI_BASE_URL"
+
 type Client struct {
-	Cache      *pokecache.Cache
+	Cache      Cache
 	HttpClient http.Client
+	BaseURL    string
+	Retry      RetryPolicy
+	Limiter    *RateLimiter
+	TTLs       CacheTTLs
+	// StaleFor is how long past its TTL a response may still be served
+	// while it is revalidated. Zero turns stale-while-revalidate off.
+	StaleFor time.Duration
+
+	// Offline, when set, answers every request from a snapshot instead of
+	// the network.
+	Offline *Snapshot
+
+	flights   flightGroup
+	refreshes sync.WaitGroup
+	recordMu  sync.Mutex
+	recorder  *Snapshot
+}
+
+// CacheTTLs sets how long each kind of response is cached. Location data
+// rarely changes and pokemon data practically never does.
+type CacheTTLs struct {
+	Locations     time.Duration
+	LocationAreas time.Duration
+	Pokemon       time.Duration
+}
+
+var DefaultCacheTTLs = CacheTTLs{
+	Locations:     24 * time.Hour,
+	LocationAreas: 24 * time.Hour,
+	Pokemon:       7 * 24 * time.Hour,
+}
+
+// Option customises a Client built by NewClient.
+type Option func(*Client)
+
+// WithBaseURL points the client at a different PokeAPI, e.g. a self-hosted
+// mirror or an httptest server. An empty url leaves the default in place.
+func WithBaseURL(url string) Option {
+	return func(c *Client) {
+		if url != "" {
+			c.BaseURL = strings.TrimRight(url, "/")
+		}
+	}
+}
+
+// WithCacheTTLs replaces DefaultCacheTTLs. Zero fields keep their default.
+func WithCacheTTLs(ttls CacheTTLs) Option {
+	return func(c *Client) {
+		if ttls.Locations > 0 {
+			c.TTLs.Locations = ttls.Locations
+		}
+		if ttls.LocationAreas > 0 {
+			c.TTLs.LocationAreas = ttls.LocationAreas
+		}
+		if ttls.Pokemon > 0 {
+			c.TTLs.Pokemon = ttls.Pokemon
+		}
+	}
+}
+
+// DefaultStaleFor is used by NewClient unless WithStaleWhileRevalidate says otherwise.
+const DefaultStaleFor = 7 * 24 * time.Hour
+
+// WithStaleWhileRevalidate sets Client.StaleFor.
+func WithStaleWhileRevalidate(staleFor time.Duration) Option {
+	return func(c *Client) {
+		c.StaleFor = staleFor
+	}
+}
+
+// WithCache makes the client use an existing cache, such as one from
+// pokecache.NewPersistentCache or a pokecache.NoopCache, instead of
+// creating an in-memory one.
+func WithCache(cache Cache) Option {
+	return func(c *Client) {
+		c.Cache = cache
+	}
+}
+
+func NewClient(timeout, cacheInterval time.Duration, opts ...Option) *Client {
+	c := &Client{
+		HttpClient: http.Client{Timeout: timeout},
+		BaseURL:    DefaultBaseURL,
+		Retry:      DefaultRetryPolicy,
+		Limiter:    NewRateLimiter(DefaultRequestsPerSecond, DefaultBurst),
+		TTLs:       DefaultCacheTTLs,
+		StaleFor:   DefaultStaleFor,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.Cache == nil {
+		c.Cache = pokecache.NewCache(cacheInterval)
+	}
+	return c
+}
+
+// Close waits for background revalidations, stops the cache's background
+// work and drops idle connections.
+func (c *Client) Close() error {
+	c.refreshes.Wait()
+	c.HttpClient.CloseIdleConnections()
+	return c.Cache.Close()
+}
+
+// BaseURLFromEnv returns the base URL set in POKEAPI_BASE_URL, or
+// DefaultBaseURL when the variable is unset.
+func BaseURLFromEnv() string {
+	if url := os.Getenv(BaseURLEnv); url != "" {
+		return url
+	}
+	return DefaultBaseURL
+}
+
+// Endpoint builds an absolute URL for the given API path, e.g.
+// Endpoint("pokemon/%s", "pikachu"). String arguments are escaped so each
+// stays a single path segment, whatever the trainer typed.
+func (c *Client) Endpoint(format string, args ...any) string {
+	for i, arg := range args {
+		if name, ok := arg.(string); ok {
+			args[i] = pathSegment(name)
+		}
+	}
+	return c.BaseURL + "/" + strings.TrimLeft(fmt.Sprintf(format, args...), "/")
 }
 
-func NewClient(timeout, cacheInterval time.Duration) *Client {
-	return &Client{
-		Cache:      pokecache.NewCache(cacheInterval),
-		HttpClient: http.Client{},
+// pathSegment escapes name for use as one segment of a URL path. Dot
+// segments are escaped too, since they would otherwise move up the path.
+func pathSegment(name string) string {
+	switch name {
+	case ".":
+		return "%2E"
+	case "..":
+		return "%2E%2E"
+	}
+	return url.PathEscape(name)
+}
+
+// ListLocations fetches a page of locations. An empty pageURL fetches the
+// first page; otherwise pass the Next or Previous URL of an earlier page.
+func (c *Client) ListLocations(ctx context.Context, pageURL string) (GetLocationsResponse, error) {
+	if pageURL == "" {
+		pageURL = c.Endpoint("location/")
+	}
+	var locations GetLocationsResponse
+	err := c.get(ctx, pageURL, c.TTLs.Locations, &locations)
+	return locations, err
+}
+
+// GetRegion fetches a region and the locations in it.
+func (c *Client) GetRegion(ctx context.Context, name string) (RegionResponse, error) {
+	var region RegionResponse
+	err := c.get(ctx, c.Endpoint("region/%s", name), c.TTLs.Locations, &region)
+	return region, err
+}
+
+// GetLocation fetches a location and the areas it is divided into.
+func (c *Client) GetLocation(ctx context.Context, name string) (LocationResponse, error) {
+	var location LocationResponse
+	err := c.get(ctx, c.Endpoint("location/%s", name), c.TTLs.Locations, &location)
+	return location, err
+}
+
+// GetGeneration fetches a generation and the species it introduced.
+func (c *Client) GetGeneration(ctx context.Context, name string) (GenerationResponse, error) {
+	var generation GenerationResponse
+	err := c.get(ctx, c.Endpoint("generation/%s", name), c.TTLs.Pokemon, &generation)
+	return generation, err
+}
+
+// GetPokemonSpecies fetches a species and its varieties.
+func (c *Client) GetPokemonSpecies(ctx context.Context, name string) (PokemonSpeciesResponse, error) {
+	var species PokemonSpeciesResponse
+	err := c.get(ctx, c.Endpoint("pokemon-species/%s", name), c.TTLs.Pokemon, &species)
+	return species, err
+}
+
+// GetLocationArea fetches a location area and the pokemon that can be
+// encountered there.
+func (c *Client) GetLocationArea(ctx context.Context, name string) (PokemonEncountersResponse, error) {
+	var area PokemonEncountersResponse
+	err := c.get(ctx, c.Endpoint("location-area/%s", name), c.TTLs.LocationAreas, &area)
+	return area, err
+}
+
+// GetPokemon fetches a single pokemon by name or national dex number.
+func (c *Client) GetPokemon(ctx context.Context, name string) (PokemonInformation, error) {
+	var pokemon PokemonInformation
+	err := c.get(ctx, c.Endpoint("pokemon/%s", name), c.TTLs.Pokemon, &pokemon)
+	return pokemon, err
+}
+
+// get decodes the JSON at url into v, serving it from the cache when
+// possible and caching successful responses for ttl otherwise. A stale
+// cached response is served straight away while it is revalidated in the
+// background. Concurrent misses for the same url share a single request,
+// made with the first caller's ctx; cancelling ctx abandons the request and
+// surfaces as a NetworkError.
+func (c *Client) get(ctx context.Context, url string, ttl time.Duration, v any) error {
+	body, header, fresh, exists := c.Cache.Lookup(url)
+	switch {
+	case exists && !fresh && c.StaleFor > 0:
+		c.revalidate(url, body, header, ttl)
+	case !exists || !fresh:
+		var err error
+		body, err = c.flights.do(url, func() ([]byte, error) {
+			return c.load(ctx, url, body, header, ttl)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return &DecodeError{URL: url, Err: err}
+	}
+	if recorder := c.Recording(); recorder != nil {
+		// Failures are kept on the recorder rather than failing the lookup.
+		recorder.Write(c.BaseURL, url, body)
+	}
+	return nil
+}
+
+// revalidate refreshes a stale cache entry without making the caller wait.
+// Close waits for revalidations still in flight.
+func (c *Client) revalidate(url string, staleBody []byte, header http.Header, ttl time.Duration) {
+	c.refreshes.Add(1)
+	go func() {
+		defer c.refreshes.Done()
+		c.flights.do(url, func() ([]byte, error) {
+			return c.load(context.Background(), url, staleBody, header, ttl)
+		})
+	}()
+}
+
+// load fetches url and caches the result. When a stale copy is available
+// its validators are sent along, and a 304 Not Modified just marks the
+// stale copy fresh again.
+func (c *Client) load(ctx context.Context, url string, staleBody []byte, header http.Header, ttl time.Duration) ([]byte, error) {
+	res, err := c.fetch(ctx, url, header)
+	if err != nil {
+		return nil, err
+	}
+	if res.notModified && staleBody != nil {
+		c.Cache.Refresh(url, ttl, c.StaleFor)
+		return staleBody, nil
+	}
+	if !json.Valid(res.body) {
+		return nil, &DecodeError{URL: url, Err: errors.New("response is not valid JSON")}
+	}
+	c.Cache.AddWithHeader(url, res.body, res.header, ttl, c.StaleFor)
+	return res.body, nil
+}
+
+// fetchResult is a successful response: either a body or, for a
+// conditional request, confirmation that the cached copy is still current.
+type fetchResult struct {
+	body        []byte
+	header      http.Header
+	notModified bool
+}
+
+// fetch GETs url, retrying transient failures according to c.Retry. The
+// ETag and Last-Modified values in validators, if any, make it a
+// conditional request. In offline mode the snapshot answers instead.
+func (c *Client) fetch(ctx context.Context, url string, validators http.Header) (fetchResult, error) {
+	if c.Offline != nil {
+		body, err := c.Offline.Read(c.BaseURL, url)
+		return fetchResult{body: body}, err
+	}
+	for attempt := 1; ; attempt++ {
+		res, err := c.fetchOnce(ctx, url, validators)
+		if err == nil || attempt >= c.Retry.MaxAttempts || !shouldRetry(ctx, err) {
+			return res, err
+		}
+		var retryAfter time.Duration
+		var statusErr *UpstreamStatusError
+		if errors.As(err, &statusErr) {
+			retryAfter = statusErr.RetryAfter
+		}
+		delay, ok := c.Retry.delay(attempt, retryAfter)
+		if !ok {
+			return res, err
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return fetchResult{}, &NetworkError{URL: url, Err: err}
+		}
+	}
+}
+
+// fetchOnce performs a single GET and maps failures onto the client's error types.
+func (c *Client) fetchOnce(ctx context.Context, url string, validators http.Header) (fetchResult, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fetchResult{}, &RequestError{URL: url, Err: err}
+	}
+	if c.Limiter != nil {
+		if err := c.Limiter.Wait(ctx); err != nil {
+			return fetchResult{}, &NetworkError{URL: url, Err: err}
+		}
+	}
+	if etag := validators.Get("ETag"); etag != "" {
+		request.Header.Set("If-None-Match", etag)
+	}
+	if lastModified := validators.Get("Last-Modified"); lastModified != "" {
+		request.Header.Set("If-Modified-Since", lastModified)
+	}
+	response, err := c.HttpClient.Do(request)
+	if err != nil {
+		return fetchResult{}, &NetworkError{URL: url, Err: err}
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return fetchResult{}, &NetworkError{URL: url, Err: err}
+	}
+	if response.StatusCode == http.StatusNotModified {
+		return fetchResult{header: response.Header, notModified: true}, nil
+	}
+	if response.StatusCode == http.StatusNotFound {
+		return fetchResult{}, &NotFoundError{URL: url}
+	}
+	if response.StatusCode > 299 {
+		return fetchResult{}, &UpstreamStatusError{
+			URL:        url,
+			Code:       response.StatusCode,
+			Body:       body,
+			RetryAfter: parseRetryAfter(response.Header.Get("Retry-After"), time.Now()),
+		}
 	}
+	return fetchResult{body: body, header: response.Header}, nil
 }
@@ -0,0 +1,109 @@
+package pokeapiclient
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultRequestsPerSecond and DefaultBurst keep bulk lookups well inside
+// PokeAPI's fair-use policy.
+const (
+	DefaultRequestsPerSecond = 10
+	DefaultBurst             = 10
+)
+
+// RateLimiter is a token bucket shared by every goroutine using a Client.
+// Each outgoing request, retries included, takes one token.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	stats  RateLimiterStats
+}
+
+// RateLimiterStats describes how much the limiter has slowed requests down.
+type RateLimiterStats struct {
+	RequestsPerSecond float64
+	Burst             int
+	Requests          int
+	Delayed           int
+	TotalWait         time.Duration
+	MaxWait           time.Duration
+}
+
+func NewRateLimiter(requestsPerSecond float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:   requestsPerSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+		stats:  RateLimiterStats{RequestsPerSecond: requestsPerSecond, Burst: burst},
+	}
+}
+
+// WithRateLimit replaces the default limiter. A non-positive rate turns
+// rate limiting off.
+func WithRateLimit(requestsPerSecond float64, burst int) Option {
+	return func(c *Client) {
+		if requestsPerSecond <= 0 {
+			c.Limiter = nil
+			return
+		}
+		c.Limiter = NewRateLimiter(requestsPerSecond, burst)
+	}
+}
+
+// Wait blocks until a token is available or ctx is done.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	wait := l.reserve(time.Now())
+	if wait <= 0 {
+		return nil
+	}
+	if err := sleep(ctx, wait); err != nil {
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Stats returns a snapshot of the limiter's counters.
+func (l *RateLimiter) Stats() RateLimiterStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stats
+}
+
+// reserve takes a token, going into debt if none are left, and returns how
+// long the caller must wait for the debt to be paid off.
+func (l *RateLimiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	l.tokens--
+
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.stats.Requests++
+	if wait > 0 {
+		l.stats.Delayed++
+		l.stats.TotalWait += wait
+		if wait > l.stats.MaxWait {
+			l.stats.MaxWait = wait
+		}
+	}
+	return wait
+}
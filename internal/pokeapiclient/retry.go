@@ -0,0 +1,111 @@
+package pokeapiclient
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how the client retries GET requests that failed for
+// transient reasons: network errors, 429 Too Many Requests and 5xx responses.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of tries, including the first one.
+	// Values below 2 disable retrying.
+	MaxAttempts int
+	// BaseDelay is the wait before the first retry; it doubles every attempt.
+	// Zero retries straight away.
+	BaseDelay time.Duration
+	// MaxDelay caps the exponential backoff. A server asking, through
+	// Retry-After, for a longer wait than this gets its error returned
+	// instead of a retry.
+	MaxDelay time.Duration
+	// Jitter is the fraction (0 to 1) of each delay that is randomised so
+	// concurrent callers don't retry in lockstep.
+	Jitter float64
+}
+
+// DefaultRetryPolicy is used by NewClient unless WithRetryPolicy says otherwise.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   200 * time.Millisecond,
+	MaxDelay:    5 * time.Second,
+	Jitter:      0.2,
+}
+
+// NoRetry makes every request a single attempt.
+var NoRetry = RetryPolicy{MaxAttempts: 1}
+
+// WithRetryPolicy replaces DefaultRetryPolicy.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.Retry = policy
+	}
+}
+
+// delay returns how long to wait before the next attempt. A Retry-After
+// value sent by the server wins over the computed backoff; ok is false when
+// it asks for longer than MaxDelay, so the caller should stop retrying.
+func (p RetryPolicy) delay(attempt int, retryAfter time.Duration) (d time.Duration, ok bool) {
+	if retryAfter > 0 {
+		if p.MaxDelay > 0 && retryAfter > p.MaxDelay {
+			return 0, false
+		}
+		return retryAfter, true
+	}
+	d = time.Duration(math.MaxInt64)
+	if shift := attempt - 1; shift < 63 && p.BaseDelay <= d>>shift {
+		d = p.BaseDelay << shift
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		d -= time.Duration(p.Jitter * rand.Float64() * float64(d))
+	}
+	return d, true
+}
+
+// shouldRetry reports whether a failed attempt is worth repeating. The
+// client only sends GET requests, which are always safe to repeat.
+func shouldRetry(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var statusErr *UpstreamStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Code == http.StatusTooManyRequests || statusErr.Code >= 500
+	}
+	var networkErr *NetworkError
+	return errors.As(err, &networkErr)
+}
+
+// parseRetryAfter understands both forms of the Retry-After header:
+// a number of seconds or an HTTP date.
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := http.ParseTime(header); err == nil && when.After(now) {
+		return when.Sub(now)
+	}
+	return 0
+}
+
+// sleep waits for d or until ctx is done, whichever comes first.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,147 @@
+package pokeapiclient
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Snapshot is a directory of PokeAPI responses laid out like the API
+// itself: <base>/pokemon/pikachu is stored as pokemon/pikachu.json, and a
+// query string is appended after an @, as in location@offset=20&limit=20.json.
+// A Client can answer requests from a snapshot (offline mode) or record the
+// responses it sees into one.
+type Snapshot struct {
+	Dir string
+
+	mu      sync.Mutex
+	written int
+	lastErr error
+}
+
+func NewSnapshot(dir string) *Snapshot {
+	return &Snapshot{Dir: dir}
+}
+
+// NotInSnapshotError is returned in offline mode for anything the snapshot
+// does not contain.
+type NotInSnapshotError struct {
+	URL  string
+	Path string
+}
+
+func (e *NotInSnapshotError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("%s is not in the offline snapshot", e.URL)
+	}
+	return fmt.Sprintf("%s is not in the offline snapshot (looked for %s)", e.URL, e.Path)
+}
+
+// WithOfflineSnapshot makes the client answer every request from the
+// snapshot in dir instead of the network.
+func WithOfflineSnapshot(dir string) Option {
+	return func(c *Client) {
+		if dir != "" {
+			c.Offline = NewSnapshot(dir)
+		}
+	}
+}
+
+// WithRecorder makes the client write every response it hands out into the
+// snapshot in dir.
+func WithRecorder(dir string) Option {
+	return func(c *Client) {
+		if dir != "" {
+			c.StartRecording(dir)
+		}
+	}
+}
+
+// StartRecording writes every response the client hands out from now on,
+// cached or not, into the snapshot in dir.
+func (c *Client) StartRecording(dir string) *Snapshot {
+	c.recordMu.Lock()
+	defer c.recordMu.Unlock()
+	c.recorder = NewSnapshot(dir)
+	return c.recorder
+}
+
+// StopRecording stops recording and returns the snapshot that was being
+// written, or nil if the client was not recording.
+func (c *Client) StopRecording() *Snapshot {
+	c.recordMu.Lock()
+	defer c.recordMu.Unlock()
+	recorder := c.recorder
+	c.recorder = nil
+	return recorder
+}
+
+// Recording returns the snapshot being written, or nil.
+func (c *Client) Recording() *Snapshot {
+	c.recordMu.Lock()
+	defer c.recordMu.Unlock()
+	return c.recorder
+}
+
+// path maps url, which must live under baseURL, to a file in the snapshot.
+func (s *Snapshot) path(baseURL, url string) (string, error) {
+	relative := strings.TrimPrefix(url, baseURL)
+	if relative == url {
+		return "", fmt.Errorf("%s is not under %s", url, baseURL)
+	}
+	relative, query, _ := strings.Cut(relative, "?")
+	relative = strings.Trim(relative, "/")
+	if relative == "" {
+		return "", fmt.Errorf("%s does not name a resource", url)
+	}
+	if query != "" {
+		relative += "@" + query
+	}
+	dir := filepath.Clean(s.Dir)
+	path := filepath.Join(dir, filepath.FromSlash(relative)+".json")
+	if !strings.HasPrefix(path, dir+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s escapes the snapshot directory", url)
+	}
+	return path, nil
+}
+
+// Read returns the stored response for url.
+func (s *Snapshot) Read(baseURL, url string) ([]byte, error) {
+	path, err := s.path(baseURL, url)
+	if err != nil {
+		return nil, &NotInSnapshotError{URL: url}
+	}
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &NotInSnapshotError{URL: url, Path: path}
+	}
+	return body, nil
+}
+
+// Write stores body as the response for url.
+func (s *Snapshot) Write(baseURL, url string, body []byte) error {
+	path, err := s.path(baseURL, url)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(path), 0o755)
+	}
+	if err == nil {
+		err = os.WriteFile(path, body, 0o644)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.lastErr = err
+		return err
+	}
+	s.written++
+	return nil
+}
+
+// Status reports how many responses were recorded and the last write error.
+func (s *Snapshot) Status() (written int, lastErr error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.written, s.lastErr
+}
@@ -0,0 +1,154 @@
+package pokecache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// diskStore mirrors cache entries into a directory, one JSON file per key,
+// so they survive between sessions.
+type diskStore struct {
+	dir string
+}
+
+type diskEntry struct {
+	Key        string      `json:"key"`
+	Time       time.Time   `json:"time"`
+	Expires    time.Time   `json:"expires"`
+	StaleUntil time.Time   `json:"stale_until"`
+	Header     http.Header `json:"header,omitempty"`
+	Val        []byte      `json:"val"`
+}
+
+// NewPersistentCache returns a Cache backed by dir. Entries already in dir
+// that are still fresh or within their stale window are loaded straight
+// away; the rest are deleted.
+func NewPersistentCache(dir string, interval time.Duration, opts ...Option) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	c := newCache(interval, opts)
+	c.disk = &diskStore{dir: dir}
+	entries, err := c.disk.load()
+	if err != nil {
+		return nil, err
+	}
+	// Oldest first, so the newest entries end up most recently used.
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+	now := time.Now().UTC()
+	for _, entry := range entries {
+		loaded := CacheEntry{
+			time:       entry.Time,
+			expires:    entry.Expires,
+			staleUntil: entry.StaleUntil,
+			val:        entry.Val,
+			header:     entry.Header,
+		}
+		if loaded.expires.IsZero() {
+			loaded.expires = loaded.time.Add(interval)
+		}
+		if loaded.staleUntil.Before(loaded.expires) {
+			loaded.staleUntil = loaded.expires
+		}
+		if loaded.dead(now) {
+			c.disk.remove(entry.Key)
+			continue
+		}
+		c.insert(entry.Key, loaded)
+	}
+	c.mu.Lock()
+	c.evict()
+	c.unlock()
+
+	c.start()
+	return c, nil
+}
+
+// DefaultDir is where the persistent cache lives unless told otherwise.
+func DefaultDir() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "pokedex", "http"), nil
+}
+
+func (d *diskStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(d.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// save writes the entry to a temporary file first so a crash never leaves
+// a half-written entry behind.
+func (d *diskStore) save(key string, entry CacheEntry) error {
+	data, err := json.Marshal(diskEntry{
+		Key:        key,
+		Time:       entry.time,
+		Expires:    entry.expires,
+		StaleUntil: entry.staleUntil,
+		Header:     entry.header,
+		Val:        entry.val,
+	})
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(d.dir, "entry-*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), d.path(key))
+}
+
+func (d *diskStore) remove(key string) {
+	os.Remove(d.path(key))
+}
+
+// load reads every entry in the directory, skipping files it can't decode.
+// Temporary files left behind by a save that never finished are deleted.
+func (d *diskStore) load() ([]diskEntry, error) {
+	files, err := os.ReadDir(d.dir)
+	if err != nil {
+		return nil, err
+	}
+	entries := []diskEntry{}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		if strings.HasPrefix(file.Name(), "entry-") && strings.HasSuffix(file.Name(), ".tmp") {
+			os.Remove(filepath.Join(d.dir, file.Name()))
+			continue
+		}
+		if !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(d.dir, file.Name()))
+		if err != nil {
+			continue
+		}
+		var entry diskEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
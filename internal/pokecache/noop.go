@@ -0,0 +1,39 @@
+package pokecache
+
+import (
+	"net/http"
+	"time"
+)
+
+// NoopCache stores nothing, so every lookup goes to the network. It is
+// useful for debugging and for measuring the API without the cache.
+type NoopCache struct{}
+
+func (NoopCache) Get(key string) ([]byte, bool) {
+	return nil, false
+}
+
+func (NoopCache) Add(key string, val []byte) {}
+
+func (NoopCache) AddWithHeader(key string, val []byte, header http.Header, ttl, staleFor time.Duration) {
+}
+
+func (NoopCache) Lookup(key string) ([]byte, http.Header, bool, bool) {
+	return nil, nil, false, false
+}
+
+func (NoopCache) Refresh(key string, ttl, staleFor time.Duration) bool {
+	return false
+}
+
+func (NoopCache) Remove(key string) bool {
+	return false
+}
+
+func (NoopCache) Len() int {
+	return 0
+}
+
+func (NoopCache) Close() error {
+	return nil
+}
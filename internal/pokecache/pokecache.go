@@ -1,75 +1,427 @@
 package pokecache
 
 import (
+	"container/list"
+	"net/http"
 	"sync"
 	"time"
 )
 
 type CacheEntry struct {
-	time time.Time
-	val  []byte
+	time    time.Time
+	expires time.Time
+	// staleUntil is when the entry is dropped. Between expires and
+	// staleUntil it is only handed out by Lookup, for revalidation.
+	staleUntil time.Time
+	val        []byte
+	header     http.Header
+	elem       *list.Element
+}
+
+func (e CacheEntry) expired(now time.Time) bool {
+	return !now.Before(e.expires)
+}
+
+func (e CacheEntry) dead(now time.Time) bool {
+	return !now.Before(e.staleUntil)
 }
 
 type Cache struct {
 	cache map[string]CacheEntry
 	mu    *sync.Mutex
+	disk  *diskStore
+	// diskMu serialises writes and removals of files on disk; see syncDisk.
+	// It is never taken while holding mu.
+	diskMu sync.Mutex
+	// deleted lists keys dropped from memory whose files are yet to be
+	// removed; unlock removes them once mu is released.
+	deleted []string
+
+	// lru orders keys from most (front) to least (back) recently used.
+	lru         *list.List
+	bytes       int
+	maxEntries  int
+	maxBytes    int
+	evictions   int
+	expirations int
+	hits        int
+	staleHits   int
+	misses      int
+	// diskErrors counts failed writes to disk; lastDiskErr is the latest.
+	diskErrors  int
+	lastDiskErr error
+
+	// interval is how long entries added with Add live, and how often the
+	// background reaper sweeps expired entries.
+	interval   time.Duration
+	background bool
+	done       chan struct{}
+	closeOnce  sync.Once
+}
+
+// Option customises a Cache built by NewCache or NewPersistentCache.
+type Option func(*Cache)
+
+// WithoutReaper skips the background reaper goroutine. Expired entries are
+// then only dropped when Get finds them, so nothing needs to be cleaned up.
+func WithoutReaper() Option {
+	return func(c *Cache) {
+		c.background = false
+	}
+}
+
+// WithMaxEntries caps the number of entries; the least recently used entry
+// is evicted to make room. Zero means no limit.
+func WithMaxEntries(n int) Option {
+	return func(c *Cache) {
+		c.maxEntries = n
+	}
+}
+
+// WithMaxBytes caps the total size of the cached values; least recently used
+// entries are evicted to make room. Zero means no limit.
+func WithMaxBytes(n int) Option {
+	return func(c *Cache) {
+		c.maxBytes = n
+	}
 }
 
 func (cache *Cache) Length() int {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
 	return len(cache.cache)
 }
-func NewCache(interval time.Duration) *Cache {
-	c := Cache{
-		cache: make(map[string]CacheEntry),
-		mu:    &sync.Mutex{},
-	}
 
-	go c.reapLoop(interval)
-	return &c
+// Len is Length under the name the client's Cache interface uses.
+func (c *Cache) Len() int {
+	return c.Length()
 }
 
-func (c *Cache) Add(key string, val []byte) {
+// Bytes returns the total size of the cached values.
+func (c *Cache) Bytes() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.bytes
+}
+
+// Evictions returns how many entries were dropped to stay within the size limits.
+func (c *Cache) Evictions() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.evictions
+}
+
+// Expirations returns how many entries were dropped for being too old.
+func (c *Cache) Expirations() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.expirations
+}
+
+// Stats summarises what the cache holds and how well it has been doing.
+type Stats struct {
+	Entries     int
+	Bytes       int
+	Hits        int
+	StaleHits   int
+	Misses      int
+	Evictions   int
+	Expirations int
+	// DiskErrors counts entries a persistent cache failed to write to disk;
+	// LastDiskError is the most recent of those failures.
+	DiskErrors    int
+	LastDiskError error
+}
+
+// EntryInfo describes a single cached entry.
+type EntryInfo struct {
+	Key       string
+	Size      int
+	Age       time.Duration
+	ExpiresIn time.Duration
+}
+
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return Stats{
+		Entries:       len(c.cache),
+		Bytes:         c.bytes,
+		Hits:          c.hits,
+		StaleHits:     c.staleHits,
+		Misses:        c.misses,
+		Evictions:     c.evictions,
+		Expirations:   c.expirations,
+		DiskErrors:    c.diskErrors,
+		LastDiskError: c.lastDiskErr,
+	}
+}
+
+// Entries lists what is cached, most recently used first.
+func (c *Cache) Entries() []EntryInfo {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.cache[key] = CacheEntry{
-		time: time.Now(),
-		val:  val,
+	now := time.Now().UTC()
+	entries := make([]EntryInfo, 0, c.lru.Len())
+	for elem := c.lru.Front(); elem != nil; elem = elem.Next() {
+		key := elem.Value.(string)
+		entry := c.cache[key]
+		entries = append(entries, EntryInfo{
+			Key:       key,
+			Size:      len(entry.val),
+			Age:       now.Sub(entry.time),
+			ExpiresIn: entry.expires.Sub(now),
+		})
+	}
+	return entries
+}
+
+// Clear removes every entry and returns how many there were.
+func (c *Cache) Clear() int {
+	c.mu.Lock()
+	defer c.unlock()
+	n := len(c.cache)
+	for key := range c.cache {
+		c.delete(key)
+	}
+	return n
+}
+
+func NewCache(interval time.Duration, opts ...Option) *Cache {
+	c := newCache(interval, opts)
+	c.start()
+	return c
+}
+
+func newCache(interval time.Duration, opts []Option) *Cache {
+	c := &Cache{
+		cache:      make(map[string]CacheEntry),
+		mu:         &sync.Mutex{},
+		lru:        list.New(),
+		interval:   interval,
+		background: true,
+		done:       make(chan struct{}),
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// start launches the reaper unless it was disabled with WithoutReaper.
+func (c *Cache) start() {
+	if c.background {
+		go c.reapLoop(c.interval)
+	}
+}
+
+// Close stops the background reaper. The cache stays usable afterwards but
+// expired entries are then only dropped by Get. Close is safe to call more
+// than once.
+func (c *Cache) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+	return nil
+}
+
+// Add stores val for the cache's default interval.
+func (c *Cache) Add(key string, val []byte) {
+	c.AddWithTTL(key, val, c.interval)
+}
+
+// AddWithTTL stores val until ttl has passed.
+func (c *Cache) AddWithTTL(key string, val []byte, ttl time.Duration) {
+	c.AddWithHeader(key, val, nil, ttl, 0)
+}
 
+// AddWithHeader stores val together with the response headers it came
+// with. The entry is fresh for ttl and then kept as stale for staleFor more,
+// during which Lookup still returns it so it can be revalidated.
+func (c *Cache) AddWithHeader(key string, val []byte, header http.Header, ttl, staleFor time.Duration) {
+	c.mu.Lock()
+	now := time.Now().UTC()
+	c.insert(key, CacheEntry{
+		time:       now,
+		expires:    now.Add(ttl),
+		staleUntil: now.Add(ttl + staleFor),
+		val:        val,
+		header:     header,
+	})
+	c.evict()
+	c.unlock()
+	c.syncDisk(key)
 }
 
 func (c *Cache) Get(key string) ([]byte, bool) {
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	defer c.unlock()
 	value, exists := c.cache[key]
-	if exists {
+	now := time.Now().UTC()
+	if exists && value.dead(now) {
+		c.delete(key)
+		c.expirations++
+		exists = false
+	}
+	if exists && !value.expired(now) {
+		c.hits++
+		c.lru.MoveToFront(value.elem)
 		return value.val, exists
 	}
+	c.misses++
 	return nil, false
 }
 
+// Lookup is like Get but also returns stale entries, along with their
+// response headers, so the caller can serve them while revalidating.
+// fresh reports whether the entry is still within its TTL.
+func (c *Cache) Lookup(key string) (val []byte, header http.Header, fresh bool, ok bool) {
+	c.mu.Lock()
+	defer c.unlock()
+	value, exists := c.cache[key]
+	now := time.Now().UTC()
+	if exists && value.dead(now) {
+		c.delete(key)
+		c.expirations++
+		exists = false
+	}
+	if !exists {
+		c.misses++
+		return nil, nil, false, false
+	}
+	fresh = !value.expired(now)
+	if fresh {
+		c.hits++
+	} else {
+		c.staleHits++
+	}
+	c.lru.MoveToFront(value.elem)
+	return value.val, value.header, fresh, true
+}
+
+// Refresh marks an entry fresh again for ttl, keeping it stale for staleFor
+// after that, e.g. after the server answered 304 Not Modified. It reports
+// whether the entry was still cached.
+func (c *Cache) Refresh(key string, ttl, staleFor time.Duration) bool {
+	c.mu.Lock()
+	value, exists := c.cache[key]
+	if !exists {
+		c.mu.Unlock()
+		return false
+	}
+	now := time.Now().UTC()
+	value.time = now
+	value.expires = now.Add(ttl)
+	value.staleUntil = now.Add(ttl + staleFor)
+	c.cache[key] = value
+	c.mu.Unlock()
+	c.syncDisk(key)
+	return true
+}
+
+// unlock releases c.mu and then removes the files of any entries deleted
+// while it was held.
+func (c *Cache) unlock() {
+	deleted := c.deleted
+	c.deleted = nil
+	c.mu.Unlock()
+	for _, key := range deleted {
+		c.syncDisk(key)
+	}
+}
+
+// syncDisk makes the file for key, if the cache has a disk, match what is in
+// memory now: it writes the entry if there is one and removes the file if
+// not. It runs without c.mu held so slow disks don't block readers. Calls
+// are serialised and each one looks the entry up afresh, so whichever runs
+// last after a change to key leaves the disk matching memory; a write can
+// never bring back an entry removed in the meantime. Failed writes are
+// recorded in Stats.
+func (c *Cache) syncDisk(key string) {
+	if c.disk == nil {
+		return
+	}
+	c.diskMu.Lock()
+	defer c.diskMu.Unlock()
+	c.mu.Lock()
+	entry, exists := c.cache[key]
+	c.mu.Unlock()
+	if !exists {
+		c.disk.remove(key)
+		return
+	}
+	if err := c.disk.save(key, entry); err != nil {
+		c.mu.Lock()
+		c.diskErrors++
+		c.lastDiskErr = err
+		c.mu.Unlock()
+	}
+}
+
 func (c *Cache) Remove(key string) bool {
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	defer c.unlock()
 	if _, exists := c.cache[key]; exists {
-		delete(c.cache, key)
+		c.delete(key)
+		return true
 	}
 	return false
 }
 
+// insert stores entry as the most recently used, replacing any existing
+// value for key. The caller must hold c.mu.
+func (c *Cache) insert(key string, entry CacheEntry) {
+	if old, exists := c.cache[key]; exists {
+		c.bytes -= len(old.val)
+		c.lru.Remove(old.elem)
+	}
+	entry.elem = c.lru.PushFront(key)
+	c.cache[key] = entry
+	c.bytes += len(entry.val)
+}
+
+// delete drops key from memory. The caller must hold c.mu and release it
+// with unlock, which then removes the file from disk.
+func (c *Cache) delete(key string) {
+	entry := c.cache[key]
+	c.lru.Remove(entry.elem)
+	c.bytes -= len(entry.val)
+	delete(c.cache, key)
+	if c.disk != nil {
+		c.deleted = append(c.deleted, key)
+	}
+}
+
+// evict drops least recently used entries until the cache is within its
+// limits. The caller must hold c.mu.
+func (c *Cache) evict() {
+	for c.lru.Len() > 0 &&
+		((c.maxEntries > 0 && c.lru.Len() > c.maxEntries) || (c.maxBytes > 0 && c.bytes > c.maxBytes)) {
+		c.delete(c.lru.Back().Value.(string))
+		c.evictions++
+	}
+}
+
 func (c *Cache) reapLoop(interval time.Duration) {
 	ticker := time.NewTicker(interval)
-	for range ticker.C {
-		c.reap(time.Now().UTC(), interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.reap(time.Now().UTC())
+		case <-c.done:
+			return
+		}
 	}
 }
 
-func (c *Cache) reap(now time.Time, last time.Duration) {
+func (c *Cache) reap(now time.Time) {
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	defer c.unlock()
 	for k, v := range c.cache {
-		if v.time.Before(now.Add(-last)) {
-			delete(c.cache, k)
+		if v.dead(now) {
+			c.delete(k)
+			c.expirations++
 		}
 	}
 }
@@ -0,0 +1,532 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mdwiltfong/PokeDex/internal/pokeapiclient"
+	"github.com/mdwiltfong/PokeDex/internal/types"
+	"github.com/mdwiltfong/PokeDex/internal/utils"
+)
+
+func newPokedexConfig(t *testing.T) *types.Config {
+	t.Helper()
+	server := newFixtureServer(t)
+	client := newTestClient(t, 5*time.Second, pokeapiclient.WithBaseURL(server.URL))
+	return &types.Config{
+		Client:   client,
+		Pokedex:  types.Pokedex{},
+		SavePath: filepath.Join(t.TempDir(), "pokedex.json"),
+	}
+}
+
+func TestSaveAndLoadPokedex(t *testing.T) {
+	configInput := newPokedexConfig(t)
+	utils.Explore(context.Background(), configInput, StdDependency{}, "canalave-city-area")
+	utils.Catch(context.Background(), configInput, PassDependency{}, "pikachu")
+
+	if _, err := utils.Save(context.Background(), configInput, StdDependency{}, ""); err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	configInput.Pokedex = types.Pokedex{}
+	output, err := utils.Load(context.Background(), configInput, StdDependency{}, "")
+	if err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	if output.(types.LoadCommandResponse).Count != 1 {
+		t.Fatalf(`Load should report 1 pokemon`)
+	}
+	pikachu, err := configInput.Pokedex.GetPokemon("pikachu")
+	if err != nil {
+		t.Fatalf(`Pokedex did not load pikachu`)
+	}
+	if pikachu.CaughtLocation != "canalave-city-area" || pikachu.CaughtAt.IsZero() {
+		t.Fatalf(`Expected the catch location and time to be saved but got %q and %v`, pikachu.CaughtLocation, pikachu.CaughtAt)
+	}
+}
+
+func TestLoadPokedexErrors(t *testing.T) {
+	dir := t.TempDir()
+	pokedex, err := types.LoadPokedex(filepath.Join(dir, "missing.json"))
+	if err != nil || pokedex.Len() != 0 {
+		t.Fatalf(`A missing save file should load as an empty Pokedex`)
+	}
+
+	future := filepath.Join(dir, "future.json")
+	os.WriteFile(future, []byte(`{"version": 999, "pokedex": {}}`), 0o644)
+	if _, err := types.LoadPokedex(future); err == nil {
+		t.Fatalf(`A save file from a newer version should not load`)
+	}
+
+	configInput := newPokedexConfig(t)
+	if _, err := utils.Load(context.Background(), configInput, StdDependency{}, filepath.Join(dir, "missing.json")); err == nil {
+		t.Fatalf(`The load command should fail for a missing file`)
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	configInput := newPokedexConfig(t)
+	configInput.SavePath = ""
+	configInput.Profiles = types.ProfileStore{Dir: t.TempDir()}
+	if err := configInput.SwitchProfile("ash"); err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	utils.Explore(context.Background(), configInput, StdDependency{}, "canalave-city-area")
+	utils.Catch(context.Background(), configInput, PassDependency{}, "pikachu")
+
+	output, err := utils.Profile(context.Background(), configInput, StdDependency{}, "switch misty")
+	if err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	if output.(types.ProfileCommandResponse).Message != "Now playing as misty (0 caught)" {
+		t.Fatalf(`Unexpected switch message %q`, output.(types.ProfileCommandResponse).Message)
+	}
+	if configInput.Pokedex.Len() != 0 || configInput.CurrentLocation != "" {
+		t.Fatalf(`A new profile should start with an empty Pokedex and settings`)
+	}
+
+	output, err = utils.Profile(context.Background(), configInput, StdDependency{}, "list")
+	if err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	profiles := output.Response().([]types.ProfileSummary)
+	if len(profiles) != 2 || profiles[0].Name != "ash" || profiles[0].Caught != 1 || profiles[1].Name != "misty" || profiles[1].Caught != 0 {
+		t.Fatalf(`Expected ash with 1 catch and misty with none but got %+v`, profiles)
+	}
+
+	if _, err := utils.Profile(context.Background(), configInput, StdDependency{}, "switch ash"); err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	if _, err := configInput.Pokedex.GetPokemon("pikachu"); err != nil {
+		t.Fatalf(`Switching back should restore ash's Pokedex`)
+	}
+	if configInput.CurrentLocation != "canalave-city-area" {
+		t.Fatalf(`Switching back should restore ash's settings but got location %q`, configInput.CurrentLocation)
+	}
+
+	if _, err := utils.Profile(context.Background(), configInput, StdDependency{}, "switch ../ash"); err == nil {
+		t.Fatalf(`Profile names that are not plain file names should be rejected`)
+	}
+}
+
+func TestImportLegacySave(t *testing.T) {
+	legacy := filepath.Join(t.TempDir(), "pokedex.json")
+	store := types.ProfileStore{Dir: t.TempDir()}
+	if imported, err := store.ImportLegacySave(legacy); err != nil || imported {
+		t.Fatalf(`Nothing should be imported without an old save but got %v, %v`, imported, err)
+	}
+
+	// The first saves held the full PokeAPI payload of each catch.
+	old := `{"version": 1, "pokedex": {"pikachu": {"id": 25, "name": "pikachu", "caught_at": "2026-01-01T12:00:00Z"}}}`
+	if err := os.WriteFile(legacy, []byte(old), 0o644); err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	imported, err := store.ImportLegacySave(legacy)
+	if err != nil || !imported {
+		t.Fatalf(`The old save should be imported but got %v, %v`, imported, err)
+	}
+	pokedex, err := types.LoadPokedex(store.Path(types.DefaultProfile))
+	if err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	if _, err := pokedex.GetPokemon("pikachu"); err != nil {
+		t.Fatalf(`The default profile should have the old catches`)
+	}
+
+	if imported, err := store.ImportLegacySave(legacy); err != nil || imported {
+		t.Fatalf(`An existing default profile should not be overwritten but got %v, %v`, imported, err)
+	}
+}
+
+func TestProfileSettings(t *testing.T) {
+	configInput := newBoxConfig()
+	configInput.Profiles = types.ProfileStore{Dir: t.TempDir()}
+	configInput.SavePath = configInput.Profiles.Path("ash")
+	configInput.Profile = "ash"
+	if _, err := utils.Profile(context.Background(), configInput, StdDependency{}, "set sort -exp"); err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	if _, err := utils.Profile(context.Background(), configInput, StdDependency{}, "set layout columns"); err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	if _, err := utils.Profile(context.Background(), configInput, StdDependency{}, "set sort height"); err == nil {
+		t.Fatalf(`Unknown sort orders should be rejected`)
+	}
+
+	output, err := utils.Pokedex(context.Background(), configInput, StdDependency{}, "")
+	if err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	response := output.(types.PokedexCommandResponse)
+	if !response.Columns || response.Pokemon[0].Name != "blaziken" {
+		t.Fatalf(`pokedex should default to the profile's sort and layout but got %v first, columns %v`, response.Pokemon[0].Name, response.Columns)
+	}
+	output, err = utils.Pokedex(context.Background(), configInput, StdDependency{}, "sort:dex list")
+	if err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	response = output.(types.PokedexCommandResponse)
+	if response.Columns || response.Pokemon[0].Name != "charmander" {
+		t.Fatalf(`Arguments should override the profile's settings`)
+	}
+
+	if _, err := utils.Profile(context.Background(), configInput, StdDependency{}, "switch misty"); err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	if configInput.Settings != (types.ProfileSettings{}) {
+		t.Fatalf(`A new profile should start with default settings but got %+v`, configInput.Settings)
+	}
+	if _, err := utils.Profile(context.Background(), configInput, StdDependency{}, "switch ash"); err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	if configInput.Settings.PokedexSort != "-exp" || !configInput.Settings.PokedexColumns {
+		t.Fatalf(`Switching back should restore ash's settings but got %+v`, configInput.Settings)
+	}
+}
+
+func TestCaughtPokemonRecord(t *testing.T) {
+	configInput := newPokedexConfig(t)
+	utils.Explore(context.Background(), configInput, StdDependency{}, "canalave-city-area")
+	utils.Catch(context.Background(), configInput, PassDependency{}, "pikachu")
+
+	pikachu, err := configInput.Pokedex.GetPokemon("pikachu")
+	if err != nil {
+		t.Fatalf(`Pokedex did not store pikachu`)
+	}
+	if pikachu.ID != 25 || pikachu.Species != "pikachu" || len(pikachu.Types) != 1 || pikachu.Types[0] != "electric" || !contains(pikachu.Abilities, "static") {
+		t.Fatalf(`Unexpected caught record %+v`, pikachu)
+	}
+	if pikachu.Level < 1 || pikachu.Level > 100 {
+		t.Fatalf(`Expected a level between 1 and 100 but got %d`, pikachu.Level)
+	}
+
+	output, err := utils.Inspect(context.Background(), configInput, StdDependency{}, "pikachu full")
+	if err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	details := output.(types.InspectCommandResponse).Details
+	if details == nil || details.BaseExperience != 112 {
+		t.Fatalf(`inspect full should fetch the full payload`)
+	}
+}
+
+func TestLoadMigratesFullPayloads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "v2.json")
+	os.WriteFile(path, []byte(`{
+		"version": 2,
+		"pokedex": {"pikachu": {
+			"caught": true,
+			"caught_at": "2026-01-02T03:04:05Z",
+			"caught_location": "canalave-city-area",
+			"id": 25,
+			"name": "pikachu",
+			"species": {"name": "pikachu"},
+			"types": [{"slot": 1, "type": {"name": "electric"}}],
+			"stats": [{"base_stat": 90, "stat": {"name": "speed"}}],
+			"moves": [{"move": {"name": "thunder-shock"}}]
+		}},
+		"settings": {"current_location": "canalave-city-area"}
+	}`), 0o644)
+	save, err := types.ReadSaveFile(path)
+	if err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	pikachu, err := save.Pokedex.GetPokemon("pikachu")
+	if err != nil {
+		t.Fatalf(`The migrated Pokedex should contain pikachu`)
+	}
+	if pikachu.ID != 25 || pikachu.CaughtLocation != "canalave-city-area" || pikachu.CaughtAt.IsZero() || len(pikachu.Stats) != 1 || pikachu.Stats[0].Base != 90 {
+		t.Fatalf(`Unexpected migrated record %+v`, pikachu)
+	}
+	if save.CurrentLocation != "canalave-city-area" {
+		t.Fatalf(`The current location should survive the migration`)
+	}
+}
+
+func TestCatchDuplicates(t *testing.T) {
+	configInput := newPokedexConfig(t)
+	utils.Explore(context.Background(), configInput, StdDependency{}, "canalave-city-area")
+	first, _ := utils.Catch(context.Background(), configInput, PassDependency{}, "pikachu")
+	second, _ := utils.Catch(context.Background(), configInput, PassDependency{}, "pikachu")
+
+	if configInput.Pokedex.Len() != 2 {
+		t.Fatalf(`Expected two pikachu in the box but got %d`, configInput.Pokedex.Len())
+	}
+	firstID := first.(types.PokemonInformationResponse).Caught.InstanceID
+	secondID := second.(types.PokemonInformationResponse).Caught.InstanceID
+	if firstID == secondID {
+		t.Fatalf(`Each catch should get its own instance ID but both got %d`, firstID)
+	}
+	if entry := configInput.Pokedex.Species["pikachu"]; entry.TimesCaught != 2 || entry.ID != 25 {
+		t.Fatalf(`Unexpected species entry %+v`, entry)
+	}
+
+	output, err := utils.Inspect(context.Background(), configInput, StdDependency{}, fmt.Sprintf("#%d", secondID))
+	if err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	pikachu := output.Response().(types.CaughtPokemon)
+	if pikachu.InstanceID != secondID {
+		t.Fatalf(`inspect #%d returned instance %d`, secondID, pikachu.InstanceID)
+	}
+	if iv, ok := pikachu.IVs["speed"]; !ok || iv < 0 || iv > types.MaxIV {
+		t.Fatalf(`Expected a speed IV between 0 and %d but got %v`, types.MaxIV, pikachu.IVs)
+	}
+	if _, err := utils.Inspect(context.Background(), configInput, StdDependency{}, "#99"); err == nil {
+		t.Fatalf(`Inspecting an unknown instance should fail`)
+	}
+}
+
+func TestLoadMigratesOneCatchPerSpecies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "v3.json")
+	os.WriteFile(path, []byte(`{
+		"version": 3,
+		"pokedex": {
+			"pikachu": {"id": 25, "name": "pikachu", "caught_at": "2026-01-02T00:00:00Z"},
+			"eevee": {"id": 133, "name": "eevee", "caught_at": "2026-01-01T00:00:00Z"}
+		}
+	}`), 0o644)
+	pokedex, err := types.LoadPokedex(path)
+	if err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	if pokedex.Len() != 2 || pokedex.Box[0].Name != "eevee" || pokedex.Box[0].InstanceID != 1 || pokedex.Box[1].InstanceID != 2 {
+		t.Fatalf(`Expected eevee then pikachu numbered in catch order but got %+v`, pokedex.Box)
+	}
+	if !pokedex.HasCaught("pikachu") || pokedex.NextID != 3 {
+		t.Fatalf(`Unexpected migrated Pokedex %+v`, pokedex)
+	}
+}
+
+func TestReleaseCommand(t *testing.T) {
+	configInput := newPokedexConfig(t)
+	utils.Catch(context.Background(), configInput, PassDependency{}, "pikachu")
+	utils.Catch(context.Background(), configInput, PassDependency{}, "pikachu")
+
+	if _, err := utils.Release(context.Background(), configInput, StdDependency{}, "pikachu"); err == nil {
+		t.Fatalf(`Releasing by name should fail when more than one pokemon matches`)
+	}
+
+	var prompts []string
+	configInput.Confirm = func(ctx context.Context, prompt string) bool {
+		prompts = append(prompts, prompt)
+		return false
+	}
+	utils.Release(context.Background(), configInput, StdDependency{}, "#1")
+	if len(prompts) != 1 || configInput.Pokedex.Len() != 2 {
+		t.Fatalf(`Declining should keep the pokemon but got %d prompts and %d in the box`, len(prompts), configInput.Pokedex.Len())
+	}
+
+	configInput.Confirm = func(context.Context, string) bool { return true }
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	utils.Release(cancelled, configInput, StdDependency{}, "#1")
+	if configInput.Pokedex.Len() != 2 {
+		t.Fatalf(`An interrupted release should keep the pokemon`)
+	}
+
+	output, err := utils.Release(context.Background(), configInput, StdDependency{}, "#1")
+	if err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	if released := output.Response().(types.ReleaseRecord); released.InstanceID != 1 || released.Name != "pikachu" {
+		t.Fatalf(`Unexpected release record %+v`, released)
+	}
+	if configInput.Pokedex.Len() != 1 || !configInput.Pokedex.HasCaught("pikachu") {
+		t.Fatalf(`The species should stay caught after a release`)
+	}
+
+	output, _ = utils.Release(context.Background(), configInput, StdDependency{}, "history")
+	if history := output.Response().([]types.ReleaseRecord); len(history) != 1 || history[0].InstanceID != 1 {
+		t.Fatalf(`Expected #1 in the release history but got %+v`, history)
+	}
+
+	utils.Save(context.Background(), configInput, StdDependency{}, "")
+	pokedex, _ := types.LoadPokedex(configInput.SavePath)
+	if len(pokedex.Releases) != 1 {
+		t.Fatalf(`The release history should be saved`)
+	}
+}
+
+func TestNicknameCommand(t *testing.T) {
+	configInput := newPokedexConfig(t)
+	utils.Catch(context.Background(), configInput, PassDependency{}, "pikachu")
+	utils.Catch(context.Background(), configInput, PassDependency{}, "pikachu")
+
+	if _, err := utils.Nickname(context.Background(), configInput, StdDependency{}, "pikachu Sparky"); err == nil {
+		t.Fatalf(`Nicknaming by species should fail when more than one pokemon matches`)
+	}
+	output, err := utils.Nickname(context.Background(), configInput, StdDependency{}, "#2 Sparky")
+	if err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	if nicknamed := output.Response().(types.CaughtPokemon); nicknamed.InstanceID != 2 || nicknamed.Nickname != "Sparky" {
+		t.Fatalf(`Unexpected nicknamed pokemon %+v`, nicknamed)
+	}
+	if _, err := utils.Nickname(context.Background(), configInput, StdDependency{}, "#1 sparky"); err == nil {
+		t.Fatalf(`Nicknames should be unique within the box`)
+	}
+	if _, err := utils.Nickname(context.Background(), configInput, StdDependency{}, "#1 42"); err == nil {
+		t.Fatalf(`Numeric nicknames should be rejected`)
+	}
+
+	// The REPL lowercases inspect's arguments.
+	inspected, err := utils.Inspect(context.Background(), configInput, StdDependency{}, "sparky")
+	if err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	if inspected.Response().(types.CaughtPokemon).InstanceID != 2 {
+		t.Fatalf(`inspect by nickname should find #2`)
+	}
+
+	utils.Save(context.Background(), configInput, StdDependency{}, "")
+	pokedex, _ := types.LoadPokedex(configInput.SavePath)
+	if saved, _ := pokedex.GetPokemon("#2"); saved.Nickname != "Sparky" {
+		t.Fatalf(`The nickname should be saved but got %q`, saved.Nickname)
+	}
+
+	utils.Nickname(context.Background(), configInput, StdDependency{}, "sparky")
+	if pikachu, _ := configInput.Pokedex.GetPokemon("#2"); pikachu.Nickname != "" {
+		t.Fatalf(`Leaving out the nickname should remove it`)
+	}
+}
+
+func TestSeenAndCaught(t *testing.T) {
+	configInput := newPokedexConfig(t)
+	utils.Explore(context.Background(), configInput, StdDependency{}, "canalave-city-area")
+	if !configInput.Pokedex.HasSeen("tentacool") || configInput.Pokedex.HasCaught("tentacool") {
+		t.Fatalf(`Explore should mark tentacool as seen but not caught`)
+	}
+	if entry := configInput.Pokedex.Species["tentacool"]; entry.ID != 72 || entry.FirstSeenLocation != "canalave-city-area" || entry.FirstSeenAt.IsZero() {
+		t.Fatalf(`Unexpected species entry %+v`, entry)
+	}
+
+	utils.Catch(context.Background(), configInput, FailDependency{}, "pikachu")
+	if !configInput.Pokedex.HasSeen("pikachu") || configInput.Pokedex.HasCaught("pikachu") || configInput.Pokedex.Len() != 0 {
+		t.Fatalf(`A failed catch should only mark pikachu as seen`)
+	}
+	utils.Catch(context.Background(), configInput, PassDependency{}, "pikachu")
+	if !configInput.Pokedex.HasCaught("pikachu") {
+		t.Fatalf(`A successful catch should mark pikachu as caught`)
+	}
+
+	output, err := utils.Pokedex(context.Background(), configInput, StdDependency{}, "progress")
+	if err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	progress := output.Response().([]types.GenerationProgress)
+	if progress[0].Seen != 3 || progress[0].Caught != 1 || progress[0].Generation.Total() != 151 {
+		t.Fatalf(`Expected 3 seen and 1 caught of 151 in generation I but got %+v`, progress[0])
+	}
+
+	output, _ = utils.Pokedex(context.Background(), configInput, StdDependency{}, "seen")
+	seen := output.Response().([]types.SpeciesEntry)
+	if len(seen) != 2 || seen[0].Name != "tentacool" || seen[1].Name != "tentacruel" {
+		t.Fatalf(`Expected tentacool and tentacruel to be seen but not caught but got %+v`, seen)
+	}
+}
+
+func newBoxConfig() *types.Config {
+	configInput := &types.Config{Pokedex: types.Pokedex{}}
+	day := time.Date(2026, 1, 1, 12, 0, 0, 0, time.Local)
+	for i, pokemon := range []types.CaughtPokemon{
+		{ID: 133, Name: "eevee", BaseExperience: 65, Types: []string{"normal"}, Stats: []types.StatValue{{Name: "speed", Base: 55}}, Abilities: []string{"run-away", "adaptability"}},
+		{ID: 25, Name: "pikachu", BaseExperience: 112, Types: []string{"electric"}, Stats: []types.StatValue{{Name: "speed", Base: 90}}, Abilities: []string{"static", "lightning-rod"}},
+		{ID: 4, Name: "charmander", BaseExperience: 62, Types: []string{"fire"}, Stats: []types.StatValue{{Name: "speed", Base: 65}}, Abilities: []string{"blaze"}},
+		{ID: 257, Name: "blaziken", BaseExperience: 265, Types: []string{"fire", "fighting"}, Stats: []types.StatValue{{Name: "speed", Base: 80}}, Abilities: []string{"blaze", "speed-boost"}},
+		{ID: 25, Name: "pikachu", BaseExperience: 112, Types: []string{"electric"}, Stats: []types.StatValue{{Name: "speed", Base: 90}}, Abilities: []string{"static"}},
+	} {
+		pokemon.CaughtAt = day.AddDate(0, 0, i)
+		configInput.Pokedex.AddPokemon(pokemon)
+	}
+	return configInput
+}
+
+func listedIDs(t *testing.T, output types.CallbackResponse) []int {
+	t.Helper()
+	var ids []int
+	for _, pokemon := range output.(types.PokedexCommandResponse).Pokemon {
+		ids = append(ids, pokemon.InstanceID)
+	}
+	return ids
+}
+
+func TestPokedexOrder(t *testing.T) {
+	configInput := newBoxConfig()
+	cases := map[string]string{
+		"":            "[3 2 5 1 4]",
+		"sort:name":   "[4 3 1 2 5]",
+		"sort:caught": "[1 2 3 4 5]",
+		"sort:-exp":   "[4 2 5 1 3]",
+		"sort:type":   "[2 5 3 4 1]",
+	}
+	for input, expected := range cases {
+		output, err := utils.Pokedex(context.Background(), configInput, StdDependency{}, input)
+		if err != nil {
+			t.Fatalf("Error object should be nil but was: %s", err.Error())
+		}
+		if ids := fmt.Sprint(listedIDs(t, output)); ids != expected {
+			t.Fatalf(`pokedex %s listed %s, expected %s`, input, ids, expected)
+		}
+	}
+	if _, err := utils.Pokedex(context.Background(), configInput, StdDependency{}, "sort:height"); err == nil {
+		t.Fatalf(`An unknown sort order should be rejected`)
+	}
+}
+
+func TestPokedexPages(t *testing.T) {
+	configInput := &types.Config{Pokedex: types.Pokedex{}}
+	for i := 1; i <= 25; i++ {
+		configInput.Pokedex.AddPokemon(types.CaughtPokemon{ID: i, Name: fmt.Sprintf("pokemon-%d", i)})
+	}
+	output, err := utils.Pokedex(context.Background(), configInput, StdDependency{}, "page:2 columns")
+	if err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	response := output.(types.PokedexCommandResponse)
+	if response.Pages != 2 || len(response.Pokemon) != 5 || response.Pokemon[0].ID != 21 || !response.Columns {
+		t.Fatalf(`Expected the last 5 pokemon on page 2 of 2 but got page %d of %d with %d pokemon`, response.Page, response.Pages, len(response.Pokemon))
+	}
+	if _, err := utils.Pokedex(context.Background(), configInput, StdDependency{}, "page:3"); err == nil {
+		t.Fatalf(`Asking for a page past the end should fail`)
+	}
+}
+
+func TestPokedexFilters(t *testing.T) {
+	configInput := newBoxConfig()
+	configInput.Pokedex.SetNickname(1, "Fluffy")
+	cases := map[string]string{
+		"type:fire":                   "[3 4]",
+		"stat:speed>=80":              "[2 5 4]",
+		"stat:speed<60":               "[1]",
+		"ability:blaze":               "[3 4]",
+		"generation:3":                "[4]",
+		"gen:i":                       "[3 2 5 1]",
+		"caught-after:2026-01-04":     "[5 4]",
+		"caught-before:2026-01-02":    "[1]",
+		"pika":                        "[2 5]",
+		"pikachoo":                    "[2 5]",
+		"fluff":                       "[1]",
+		"type:fire stat:speed>70":     "[4]",
+		"ability:static sort:-caught": "[5 2]",
+		"type:water":                  "[]",
+	}
+	for input, expected := range cases {
+		output, err := utils.Pokedex(context.Background(), configInput, StdDependency{}, input)
+		if err != nil {
+			t.Fatalf("pokedex %s: error object should be nil but was: %s", input, err.Error())
+		}
+		if ids := fmt.Sprint(listedIDs(t, output)); ids != expected {
+			t.Fatalf(`pokedex %s listed %s, expected %s`, input, ids, expected)
+		}
+	}
+	for _, input := range []string{"stat:speed~3", "generation:12", "colour:red", "caught-after:yesterday", "type:"} {
+		if _, err := utils.Pokedex(context.Background(), configInput, StdDependency{}, input); err == nil {
+			t.Fatalf(`pokedex %s should be rejected`, input)
+		}
+	}
+}
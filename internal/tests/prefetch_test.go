@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mdwiltfong/PokeDex/internal/pokeapiclient"
+	"github.com/mdwiltfong/PokeDex/internal/types"
+	"github.com/mdwiltfong/PokeDex/internal/utils"
+)
+
+func TestPrefetchRegion(t *testing.T) {
+	server := newFixtureServer(t)
+	client := newTestClient(t, 5*time.Second, pokeapiclient.WithBaseURL(server.URL), pokeapiclient.WithRateLimit(0, 0))
+	configInput := &types.Config{Client: client, Pokedex: types.Pokedex{}}
+
+	output, err := utils.Prefetch(context.Background(), configInput, StdDependency{}, "region sinnoh")
+	if err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	result := output.Response().(types.PrefetchCommandResponse)
+	// sinnoh, canalave-city, canalave-city-area and pikachu are in testdata;
+	// eterna-city, tentacool and tentacruel are not.
+	if result.Fetched != 4 || len(result.Failures) != 3 {
+		t.Fatalf(`Expected 4 fetched and 3 failures but got %v and %v`, result.Fetched, result.Failures)
+	}
+	if _, ok := client.Cache.Get(server.URL + "/pokemon/pikachu"); !ok {
+		t.Fatalf(`Prefetch should have cached pikachu`)
+	}
+}
+
+func TestPrefetchGenerationUsesDefaultVarieties(t *testing.T) {
+	server := newFixtureServer(t)
+	client := newTestClient(t, 5*time.Second, pokeapiclient.WithBaseURL(server.URL), pokeapiclient.WithRateLimit(0, 0))
+	configInput := &types.Config{Client: client, Pokedex: types.Pokedex{}}
+
+	output, err := utils.Prefetch(context.Background(), configInput, StdDependency{}, "generation generation-iii")
+	if err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	result := output.Response().(types.PrefetchCommandResponse)
+	// generation-iii, the deoxys species and its default pokemon, deoxys-normal.
+	if result.Fetched != 3 || len(result.Failures) != 0 {
+		t.Fatalf(`Expected 3 fetched and no failures but got %v and %v`, result.Fetched, result.Failures)
+	}
+	if _, ok := client.Cache.Get(server.URL + "/pokemon/deoxys-normal"); !ok {
+		t.Fatalf(`Prefetch should have cached deoxys-normal`)
+	}
+}
+
+func TestPrefetchUsage(t *testing.T) {
+	configInput := &types.Config{Client: newTestClient(t, 5*time.Second), Pokedex: types.Pokedex{}}
+	if _, err := utils.Prefetch(context.Background(), configInput, StdDependency{}, "region"); err == nil {
+		t.Fatalf(`Prefetch without names should fail`)
+	}
+	if _, err := utils.Prefetch(context.Background(), configInput, StdDependency{}, "berry cheri"); err == nil {
+		t.Fatalf(`Prefetch of an unknown kind should fail`)
+	}
+}
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mdwiltfong/PokeDex/internal/pokeapiclient"
+)
+
+func TestRateLimiterIsSharedAcrossGoroutines(t *testing.T) {
+	limiter := pokeapiclient.NewRateLimiter(50, 2)
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := limiter.Wait(context.Background()); err != nil {
+				t.Errorf("Error object should be nil but was: %s", err.Error())
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Two requests fit in the burst, the remaining three wait 20ms each in turn.
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf(`Five requests at 50/s with burst 2 should take about 60ms but took %v`, elapsed)
+	}
+	stats := limiter.Stats()
+	if stats.Requests != 5 || stats.Delayed != 3 {
+		t.Fatalf(`Expected 5 requests and 3 delayed but got %v and %v`, stats.Requests, stats.Delayed)
+	}
+	if stats.MaxWait < 50*time.Millisecond {
+		t.Fatalf(`The last request should have waited about 60ms but MaxWait was %v`, stats.MaxWait)
+	}
+}
+
+func TestClientUsesRateLimiter(t *testing.T) {
+	server := newFixtureServer(t)
+	client := newTestClient(t, 5*time.Second, pokeapiclient.WithBaseURL(server.URL), pokeapiclient.WithRateLimit(1000, 1))
+	client.GetPokemon(context.Background(), "pikachu")
+	client.GetLocationArea(context.Background(), "canalave-city-area")
+	if requests := client.Limiter.Stats().Requests; requests != 2 {
+		t.Fatalf(`Limiter should have seen 2 requests but saw %v`, requests)
+	}
+
+	unlimited := newTestClient(t, 5*time.Second, pokeapiclient.WithRateLimit(0, 0))
+	if unlimited.Limiter != nil {
+		t.Fatalf(`A zero rate should disable the limiter`)
+	}
+}
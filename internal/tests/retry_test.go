@@ -0,0 +1,157 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mdwiltfong/PokeDex/internal/pokeapiclient"
+)
+
+var fastRetry = pokeapiclient.RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   time.Millisecond,
+	MaxDelay:    10 * time.Millisecond,
+	Jitter:      0.5,
+}
+
+// newFlakyServer fails the first `failures` requests with status, then
+// serves the pikachu fixture.
+func newFlakyServer(t *testing.T, failures int32, status int, header http.Header) (*httptest.Server, *int32) {
+	t.Helper()
+	var hits int32
+	fixtures := newFixtureServer(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) <= failures {
+			for key, values := range header {
+				w.Header()[key] = values
+			}
+			w.WriteHeader(status)
+			return
+		}
+		http.Redirect(w, r, fixtures.URL+r.URL.Path, http.StatusTemporaryRedirect)
+	}))
+	t.Cleanup(server.Close)
+	return server, &hits
+}
+
+func TestRetrySucceedsAfterTransientFailures(t *testing.T) {
+	server, hits := newFlakyServer(t, 2, http.StatusServiceUnavailable, nil)
+	client := newTestClient(t, 5*time.Second, pokeapiclient.WithBaseURL(server.URL), pokeapiclient.WithRetryPolicy(fastRetry))
+
+	pokemon, err := client.GetPokemon(context.Background(), "pikachu")
+	if err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	if pokemon.Name != "pikachu" {
+		t.Fatalf(`Pokemon should be pikachu`)
+	}
+	if *hits != 3 {
+		t.Fatalf(`Server should have been hit 3 times but was hit %v times`, *hits)
+	}
+}
+
+func TestRetryWithoutBaseDelayRetriesImmediately(t *testing.T) {
+	server, hits := newFlakyServer(t, 2, http.StatusServiceUnavailable, nil)
+	policy := pokeapiclient.RetryPolicy{MaxAttempts: 3, MaxDelay: time.Second}
+	client := newTestClient(t, 5*time.Second, pokeapiclient.WithBaseURL(server.URL), pokeapiclient.WithRetryPolicy(policy))
+
+	start := time.Now()
+	if _, err := client.GetPokemon(context.Background(), "pikachu"); err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf(`A zero BaseDelay should not wait for MaxDelay but the call took %v`, elapsed)
+	}
+	if *hits != 3 {
+		t.Fatalf(`Server should have been hit 3 times but was hit %v times`, *hits)
+	}
+}
+
+func TestRetryHonorsRetryAfter(t *testing.T) {
+	header := http.Header{"Retry-After": []string{"1"}}
+	server, hits := newFlakyServer(t, 1, http.StatusTooManyRequests, header)
+	policy := fastRetry
+	policy.MaxDelay = 2 * time.Second
+	client := newTestClient(t, 5*time.Second, pokeapiclient.WithBaseURL(server.URL), pokeapiclient.WithRetryPolicy(policy))
+
+	start := time.Now()
+	_, err := client.GetPokemon(context.Background(), "pikachu")
+	if err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Fatalf(`The client should wait out Retry-After before retrying but the call took %v`, elapsed)
+	}
+	if *hits != 2 {
+		t.Fatalf(`Server should have been hit 2 times but was hit %v times`, *hits)
+	}
+}
+
+func TestRetryAfterLongerThanMaxDelayGivesUp(t *testing.T) {
+	header := http.Header{"Retry-After": []string{"60"}}
+	server, hits := newFlakyServer(t, 1, http.StatusTooManyRequests, header)
+	client := newTestClient(t, 5*time.Second, pokeapiclient.WithBaseURL(server.URL), pokeapiclient.WithRetryPolicy(fastRetry))
+
+	start := time.Now()
+	_, err := client.GetPokemon(context.Background(), "pikachu")
+	var statusErr *pokeapiclient.UpstreamStatusError
+	if !errors.As(err, &statusErr) || statusErr.RetryAfter != 60*time.Second {
+		t.Fatalf("Error should be an UpstreamStatusError asking to retry after 60s but was: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf(`The client should give up straight away but the call took %v`, elapsed)
+	}
+	if *hits != 1 {
+		t.Fatalf(`Server should have been hit once but was hit %v times`, *hits)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	server, hits := newFlakyServer(t, 10, http.StatusInternalServerError, nil)
+	client := newTestClient(t, 5*time.Second, pokeapiclient.WithBaseURL(server.URL), pokeapiclient.WithRetryPolicy(fastRetry))
+
+	_, err := client.GetPokemon(context.Background(), "pikachu")
+	var statusErr *pokeapiclient.UpstreamStatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("Error should be an UpstreamStatusError but was: %v", err)
+	}
+	if *hits != int32(fastRetry.MaxAttempts) {
+		t.Fatalf(`Server should have been hit %v times but was hit %v times`, fastRetry.MaxAttempts, *hits)
+	}
+}
+
+func TestRetrySkipsMalformedRequests(t *testing.T) {
+	policy := fastRetry
+	policy.BaseDelay = time.Second
+	policy.MaxDelay = time.Second
+	client := newTestClient(t, 5*time.Second, pokeapiclient.WithBaseURL("http://pokeapi.invalid/%zz"), pokeapiclient.WithRetryPolicy(policy))
+
+	start := time.Now()
+	_, err := client.GetPokemon(context.Background(), "pikachu")
+	var requestErr *pokeapiclient.RequestError
+	if !errors.As(err, &requestErr) {
+		t.Fatalf("Error should be a RequestError but was: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf(`A malformed request should not be retried but the call took %v`, elapsed)
+	}
+}
+
+func TestRetrySkipsNotFound(t *testing.T) {
+	server, hits := newFlakyServer(t, 10, http.StatusNotFound, nil)
+	client := newTestClient(t, 5*time.Second, pokeapiclient.WithBaseURL(server.URL), pokeapiclient.WithRetryPolicy(fastRetry))
+
+	_, err := client.GetPokemon(context.Background(), "pikachu")
+	var notFound *pokeapiclient.NotFoundError
+	if !errors.As(err, &notFound) {
+		t.Fatalf("Error should be a NotFoundError but was: %v", err)
+	}
+	if *hits != 1 {
+		t.Fatalf(`Server should have been hit once but was hit %v times`, *hits)
+	}
+}
@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mdwiltfong/PokeDex/internal/pokeapiclient"
+	"github.com/mdwiltfong/PokeDex/internal/types"
+	"github.com/mdwiltfong/PokeDex/internal/utils"
+)
+
+func TestOfflineSnapshot(t *testing.T) {
+	// testdata uses the snapshot layout, so it doubles as an offline bundle.
+	client := newTestClient(t, 5*time.Second, pokeapiclient.WithOfflineSnapshot("testdata"))
+	configInput := &types.Config{Client: client, Pokedex: types.Pokedex{}}
+
+	output, err := utils.Catch(context.Background(), configInput, PassDependency{}, "pikachu")
+	if err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	if output.Response().(types.PokemonInformation).Name != "pikachu" {
+		t.Fatalf(`Pokemon should be pikachu`)
+	}
+
+	_, err = utils.Catch(context.Background(), configInput, PassDependency{}, "mew")
+	var missing *pokeapiclient.NotInSnapshotError
+	if !errors.As(err, &missing) {
+		t.Fatalf("Error should be a NotInSnapshotError but was: %v", err)
+	}
+}
+
+func TestRecordSnapshot(t *testing.T) {
+	server := newFixtureServer(t)
+	dir := t.TempDir()
+	client := newTestClient(t, 5*time.Second, pokeapiclient.WithBaseURL(server.URL))
+	configInput := &types.Config{Client: client, Pokedex: types.Pokedex{}}
+
+	if _, err := utils.Record(context.Background(), configInput, StdDependency{}, dir); err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	utils.Map(context.Background(), configInput, StdDependency{}, "")
+	utils.Explore(context.Background(), configInput, StdDependency{}, "canalave-city-area")
+	if _, err := utils.Record(context.Background(), configInput, StdDependency{}, "stop"); err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	utils.Catch(context.Background(), configInput, StdDependency{}, "pikachu")
+
+	for _, name := range []string{"location.json", "location-area/canalave-city-area.json"} {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			t.Fatalf(`Expected %v to be recorded: %v`, name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "pokemon", "pikachu.json")); err == nil {
+		t.Fatalf(`Nothing should be recorded after record stop`)
+	}
+
+	offline := newTestClient(t, 5*time.Second, pokeapiclient.WithBaseURL(server.URL), pokeapiclient.WithOfflineSnapshot(dir))
+	server.Close()
+	area, err := offline.GetLocationArea(context.Background(), "canalave-city-area")
+	if err != nil || len(area.PokemonEncounters) == 0 {
+		t.Fatalf("Expected the recorded area to be served offline but got error %v", err)
+	}
+}
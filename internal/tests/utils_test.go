@@ -1,7 +1,18 @@
 package utils
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -11,22 +22,35 @@ import (
 	"github.com/mdwiltfong/PokeDex/internal/utils"
 )
 
+// The fake dependencies keep to the RandInt contract, 0 <= r < n, and
+// panic on a bad n just like rand.Intn.
+
 type StdDependency struct{}
 
-func (s StdDependency) RandInt(baseExperience int) int {
-	return 1
+func (s StdDependency) RandInt(n int) int {
+	return min(1, checkedBound(n)-1)
 }
 
+// FailDependency always rolls the lowest number, so catches fail.
 type FailDependency struct{}
 
-func (s FailDependency) RandInt(baseExperience int) int {
+func (s FailDependency) RandInt(n int) int {
+	checkedBound(n)
 	return 0
 }
 
+// PassDependency always rolls the highest number, so catches succeed.
 type PassDependency struct{}
 
-func (s PassDependency) RandInt(baseExperience int) int {
-	return 100
+func (s PassDependency) RandInt(n int) int {
+	return checkedBound(n) - 1
+}
+
+func checkedBound(n int) int {
+	if n <= 0 {
+		panic(fmt.Sprintf("RandInt called with n = %d", n))
+	}
+	return n
 }
 
 func TestSanitizeInput(t *testing.T) {
@@ -40,7 +64,7 @@ func TestSanitizeInput(t *testing.T) {
 }
 
 func TestCliCommandMap(t *testing.T) {
-	expectedCommands := []string{"help", "exit", "map", "mapb", "explore", "catch", "inspect", "pokedex"}
+	expectedCommands := []string{"help", "exit", "map", "mapb", "explore", "catch", "inspect", "pokedex", "stats", "cache", "record", "prefetch", "save", "load", "release", "nickname", "profile"}
 	outputCommands := utils.CliCommandMap()
 	for key, _ := range outputCommands {
 		output := contains(expectedCommands, key)
@@ -51,20 +75,21 @@ func TestCliCommandMap(t *testing.T) {
 }
 
 func TestMap(t *testing.T) {
-	clientInput := pokeapiclient.NewClient(50000, 10000)
+	server := newFixtureServer(t)
+	clientInput := newTestClient(t, 5*time.Second, pokeapiclient.WithBaseURL(server.URL))
 	configInput := &types.Config{
 		NEXT_URL: nil,
 		PREV_URL: nil,
 		Client:   clientInput,
 	}
 
-	utils.Map(configInput, StdDependency{}, "")
+	utils.Map(context.Background(), configInput, StdDependency{}, "")
 
-	_, exists := clientInput.Cache.Get("https://pokeapi.co/api/v2/location/")
+	_, exists := clientInput.Cache.Get(server.URL + "/location/")
 	if exists == false {
-		t.Fatalf(`Map did not store the url:%v`, "https://pokeapi.co/api/v2/location/")
+		t.Fatalf(`Map did not store the url:%v`, server.URL+"/location/")
 	}
-	cacheLength := clientInput.Cache.Length()
+	cacheLength := clientInput.Cache.Len()
 	if cacheLength > 1 {
 		t.Fatalf(`Cache should be 1 but was %v instead`, cacheLength)
 	}
@@ -75,7 +100,8 @@ func TestMap(t *testing.T) {
 
 func TestMapb(t *testing.T) {
 
-	clientInput := pokeapiclient.NewClient(50000, 5*time.Second)
+	server := newFixtureServer(t)
+	clientInput := newTestClient(t, 5*time.Second, pokeapiclient.WithBaseURL(server.URL))
 
 	configInput := &types.Config{
 		NEXT_URL: nil,
@@ -83,20 +109,20 @@ func TestMapb(t *testing.T) {
 		Client:   clientInput,
 	}
 
-	output1, _ := utils.Map(configInput, StdDependency{}, "")
-	output2, _ := utils.Mapb(configInput, StdDependency{}, "")
+	output1, _ := utils.Map(context.Background(), configInput, StdDependency{}, "")
+	output2, _ := utils.Mapb(context.Background(), configInput, StdDependency{}, "")
 
 	if isEqual(output1, output2) == false {
 		t.Fatalf(`The two responses are not equal`)
 	}
-	cacheLength := clientInput.Cache.Length()
+	cacheLength := clientInput.Cache.Len()
 	if cacheLength != 1 {
 		t.Fatalf(`The cache length is %v when it should be 1`, cacheLength)
 	}
 }
 
 func TestCache(t *testing.T) {
-	cache := pokecache.NewCache(5000)
+	cache := pokecache.NewCache(5 * time.Second)
 	testInput := []byte{71, 111}
 	cache.Add("Test", testInput)
 	if cache.Length() != 1 {
@@ -161,27 +187,294 @@ func TestReapLoop(t *testing.T) {
 	}
 }
 
+func TestCacheCloseStopsReaper(t *testing.T) {
+	before := runtime.NumGoroutine()
+	client := pokeapiclient.NewClient(5*time.Second, time.Millisecond)
+	client.Cache.Add("https://example.com", []byte("testdata"))
+	if err := client.Close(); err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	client.Close()
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Fatalf(`Close should stop the reaper but goroutines went from %v to %v`, before, after)
+	}
+}
+
+func TestCacheWithoutReaperExpiresLazily(t *testing.T) {
+	before := runtime.NumGoroutine()
+	cache := pokecache.NewCache(5*time.Millisecond, pokecache.WithoutReaper())
+	if after := runtime.NumGoroutine(); after != before {
+		t.Fatalf(`WithoutReaper should not start a goroutine`)
+	}
+	cache.Add("https://example.com", []byte("testdata"))
+	if _, ok := cache.Get("https://example.com"); !ok {
+		t.Fatalf("expected to find key")
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if cache.Length() != 1 {
+		t.Fatalf(`Nothing should reap the entry in the background`)
+	}
+	if _, ok := cache.Get("https://example.com"); ok {
+		t.Fatalf("expected Get to expire the old entry")
+	}
+	if cache.Length() != 0 {
+		t.Fatalf(`Get should have removed the expired entry`)
+	}
+}
+
+func TestCacheLRUEviction(t *testing.T) {
+	cache := pokecache.NewCache(time.Hour, pokecache.WithMaxEntries(2))
+	cache.Add("a", []byte("1"))
+	cache.Add("b", []byte("2"))
+	cache.Get("a")
+	cache.Add("c", []byte("3"))
+
+	if _, ok := cache.Get("b"); ok {
+		t.Fatalf("expected the least recently used key to be evicted")
+	}
+	if _, ok := cache.Get("a"); !ok {
+		t.Fatalf("expected the recently used key to survive")
+	}
+	if cache.Evictions() != 1 {
+		t.Fatalf(`Evictions should be 1 but was %v`, cache.Evictions())
+	}
+
+	bounded := pokecache.NewCache(time.Hour, pokecache.WithMaxBytes(10))
+	bounded.Add("a", []byte("12345"))
+	bounded.Add("b", []byte("12345"))
+	if bounded.Bytes() != 10 {
+		t.Fatalf(`Bytes should be 10 but was %v`, bounded.Bytes())
+	}
+	bounded.Add("c", []byte("123"))
+	if bounded.Bytes() != 8 || bounded.Length() != 2 {
+		t.Fatalf(`Expected 2 entries and 8 bytes but got %v and %v`, bounded.Length(), bounded.Bytes())
+	}
+	if _, ok := bounded.Get("a"); ok {
+		t.Fatalf("expected the oldest entry to be evicted to stay under the byte limit")
+	}
+}
+
+func TestCacheStats(t *testing.T) {
+	cache := pokecache.NewCache(time.Hour, pokecache.WithMaxEntries(1))
+	cache.Add("a", []byte("12345"))
+	cache.Get("a")
+	cache.Get("missing")
+	cache.Add("b", []byte("123"))
+
+	stats := cache.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 || stats.Evictions != 1 {
+		t.Fatalf(`Expected 1 hit, 1 miss and 1 eviction but got %+v`, stats)
+	}
+	if stats.Entries != 1 || stats.Bytes != 3 {
+		t.Fatalf(`Expected 1 entry of 3 bytes but got %+v`, stats)
+	}
+	entries := cache.Entries()
+	if len(entries) != 1 || entries[0].Key != "b" || entries[0].ExpiresIn <= 0 {
+		t.Fatalf(`Unexpected entries %+v`, entries)
+	}
+}
+
+func TestCacheCommand(t *testing.T) {
+	server := newFixtureServer(t)
+	clientInput := newTestClient(t, 5*time.Second, pokeapiclient.WithBaseURL(server.URL))
+	configInput := &types.Config{
+		Client:  clientInput,
+		Pokedex: types.Pokedex{},
+	}
+	utils.Catch(context.Background(), configInput, StdDependency{}, "pikachu")
+	utils.Explore(context.Background(), configInput, StdDependency{}, "canalave-city-area")
+
+	output, _ := utils.Cache(context.Background(), configInput, StdDependency{}, "list")
+	if entries := output.Response().([]pokecache.EntryInfo); len(entries) != 2 {
+		t.Fatalf(`Cache list should show 2 entries but showed %v`, len(entries))
+	}
+	if _, err := utils.Cache(context.Background(), configInput, StdDependency{}, "evict pokemon/pikachu"); err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	if _, err := utils.Cache(context.Background(), configInput, StdDependency{}, "evict pokemon/pikachu"); err == nil {
+		t.Fatalf(`Evicting a missing key should fail`)
+	}
+	utils.Cache(context.Background(), configInput, StdDependency{}, "clear")
+	output, _ = utils.Cache(context.Background(), configInput, StdDependency{}, "stats")
+	if stats := output.Response().(pokecache.Stats); stats.Entries != 0 {
+		t.Fatalf(`Cache should be empty after clear but had %v entries`, stats.Entries)
+	}
+	if _, err := utils.Cache(context.Background(), configInput, StdDependency{}, "bogus"); err == nil {
+		t.Fatalf(`Unknown subcommands should fail`)
+	}
+}
+
+func TestPersistentCache(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := pokecache.NewPersistentCache(dir, time.Hour)
+	if err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	cache.Add("https://example.com", []byte("testdata"))
+	cache.Add("https://example.com/gone", []byte("removed"))
+	cache.Remove("https://example.com/gone")
+
+	reopened, err := pokecache.NewPersistentCache(dir, time.Hour)
+	if err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	val, ok := reopened.Get("https://example.com")
+	if !ok || string(val) != "testdata" {
+		t.Fatalf("expected the entry to survive a restart")
+	}
+	if reopened.Length() != 1 {
+		t.Fatalf(`Cache length should be 1 but was %v`, reopened.Length())
+	}
+
+	reopened.AddWithTTL("https://example.com/short", []byte("short"), 5*time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	expired, err := pokecache.NewPersistentCache(dir, time.Hour)
+	if err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	if _, ok := expired.Get("https://example.com/short"); ok {
+		t.Fatalf(`Expired entries should not be loaded`)
+	}
+	files, _ := os.ReadDir(dir)
+	if len(files) != 1 {
+		t.Fatalf(`Expired entries should be deleted from disk but %v files remain`, len(files))
+	}
+}
+
+func TestPersistentCacheDiskErrors(t *testing.T) {
+	dir := t.TempDir()
+	leftover := filepath.Join(dir, "entry-123.tmp")
+	if err := os.WriteFile(leftover, []byte("half a"), 0o644); err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	cache, err := pokecache.NewPersistentCache(dir, time.Hour)
+	if err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	t.Cleanup(func() { cache.Close() })
+	if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+		t.Fatalf(`Temporary files from an interrupted save should be deleted`)
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	cache.Add("https://example.com", []byte("testdata"))
+	if _, ok := cache.Get("https://example.com"); !ok {
+		t.Fatalf(`A failed disk write should still keep the entry in memory`)
+	}
+	stats := cache.Stats()
+	if stats.DiskErrors != 1 || stats.LastDiskError == nil {
+		t.Fatalf(`Expected 1 disk error but got %v (%v)`, stats.DiskErrors, stats.LastDiskError)
+	}
+}
+
+func TestPersistentCacheRemoveRacingAdd(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := pokecache.NewPersistentCache(dir, time.Hour)
+	if err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	t.Cleanup(func() { cache.Close() })
+
+	// Each entry is removed as soon as it shows up in memory, which is
+	// while its large value is still being written to disk. Clear and
+	// Remove must not be undone by that write.
+	val := make([]byte, 1<<20)
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("https://example.com/%d", i)
+		added := make(chan struct{})
+		go func() {
+			defer close(added)
+			cache.Add(key, val)
+		}()
+		for cache.Length() == 0 {
+			runtime.Gosched()
+		}
+		if i%2 == 0 {
+			cache.Remove(key)
+		} else {
+			cache.Clear()
+		}
+		<-added
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 0 {
+		t.Fatalf(`Removed entries should be gone from disk but %v files remain`, len(files))
+	}
+	reopened, err := pokecache.NewPersistentCache(dir, time.Hour)
+	if err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	t.Cleanup(func() { reopened.Close() })
+	if reopened.Length() != 0 {
+		t.Fatalf(`Removed entries should not come back after a restart but %v did`, reopened.Length())
+	}
+}
+
+func TestCacheAddWithTTL(t *testing.T) {
+	cache := pokecache.NewCache(time.Hour)
+	cache.AddWithTTL("short", []byte("testdata"), 5*time.Millisecond)
+	cache.AddWithTTL("long", []byte("testdata"), time.Hour)
+	time.Sleep(10 * time.Millisecond)
+
+	if _, ok := cache.Get("short"); ok {
+		t.Fatalf("Get should never return an expired entry")
+	}
+	if _, ok := cache.Get("long"); !ok {
+		t.Fatalf("expected to find key")
+	}
+}
+
+func TestClientCacheTTLs(t *testing.T) {
+	server := newFixtureServer(t)
+	client := newTestClient(t, 5*time.Second,
+		pokeapiclient.WithBaseURL(server.URL),
+		pokeapiclient.WithCacheTTLs(pokeapiclient.CacheTTLs{Pokemon: 5 * time.Millisecond}),
+	)
+	if client.TTLs.Locations != pokeapiclient.DefaultCacheTTLs.Locations {
+		t.Fatalf(`Unset TTLs should keep their default`)
+	}
+	client.GetPokemon(context.Background(), "pikachu")
+	client.GetLocationArea(context.Background(), "canalave-city-area")
+	time.Sleep(10 * time.Millisecond)
+
+	if _, ok := client.Cache.Get(server.URL + "/pokemon/pikachu"); ok {
+		t.Fatalf("Pokemon should have expired after its TTL")
+	}
+	if _, ok := client.Cache.Get(server.URL + "/location-area/canalave-city-area"); !ok {
+		t.Fatalf("Location areas should still be cached")
+	}
+}
+
 func TestExplore(t *testing.T) {
-	clientInput := pokeapiclient.NewClient(50000, 10000)
+	server := newFixtureServer(t)
+	clientInput := newTestClient(t, 5*time.Second, pokeapiclient.WithBaseURL(server.URL))
 	configInput := &types.Config{
 		NEXT_URL: nil,
 		PREV_URL: nil,
 		Client:   clientInput,
 	}
-	output, _ := utils.Explore(configInput, StdDependency{}, "canalave-city-area")
+	output, _ := utils.Explore(context.Background(), configInput, StdDependency{}, "canalave-city-area")
 	if output.Response() == nil {
 		t.Fatalf(`Explore returned nil response`)
 	}
 }
 
 func TestExploreError404(t *testing.T) {
-	clientInput := pokeapiclient.NewClient(50000, 10000)
+	server := newFixtureServer(t)
+	clientInput := newTestClient(t, 5*time.Second, pokeapiclient.WithBaseURL(server.URL))
 	configInput := &types.Config{
 		NEXT_URL: nil,
 		PREV_URL: nil,
 		Client:   clientInput,
 	}
-	output, err := utils.Explore(configInput, StdDependency{}, "LOL")
+	output, err := utils.Explore(context.Background(), configInput, StdDependency{}, "LOL")
 	if output.Response() == nil {
 		t.Fatalf(`Explore returned nil response`)
 	}
@@ -191,30 +484,32 @@ func TestExploreError404(t *testing.T) {
 }
 
 func TestExploreErrorNoInput(t *testing.T) {
-	clientInput := pokeapiclient.NewClient(50000, 10000)
+	server := newFixtureServer(t)
+	clientInput := newTestClient(t, 5*time.Second, pokeapiclient.WithBaseURL(server.URL))
 	configInput := &types.Config{
 		NEXT_URL: nil,
 		PREV_URL: nil,
 		Client:   clientInput,
 	}
-	_, err := utils.Explore(configInput, StdDependency{}, "")
+	_, err := utils.Explore(context.Background(), configInput, StdDependency{}, "")
 
 	if err.Error() != "Please put in a location to explore" {
 		t.Fatalf("Error object should be nil but was: %s", err.Error())
 	}
 }
 func TestExploreCache(t *testing.T) {
-	clientInput := pokeapiclient.NewClient(50000, 10000)
+	server := newFixtureServer(t)
+	clientInput := newTestClient(t, 5*time.Second, pokeapiclient.WithBaseURL(server.URL))
 	configInput := &types.Config{
 		NEXT_URL: nil,
 		PREV_URL: nil,
 		Client:   clientInput,
 	}
-	_, err := utils.Explore(configInput, StdDependency{}, "canalave-city-area")
+	_, err := utils.Explore(context.Background(), configInput, StdDependency{}, "canalave-city-area")
 	if err != nil {
 		t.Fatalf("Error object should be nil but was: %s", err.Error())
 	}
-	cacheLength := clientInput.Cache.Length()
+	cacheLength := clientInput.Cache.Len()
 	if cacheLength != 1 {
 		t.Fatalf("Cache length should be 1 but was %v", cacheLength)
 	}
@@ -222,71 +517,109 @@ func TestExploreCache(t *testing.T) {
 }
 
 func TestCatchCommandFailCatch(t *testing.T) {
-	clientInput := pokeapiclient.NewClient(50000, 10000)
+	server := newFixtureServer(t)
+	clientInput := newTestClient(t, 5*time.Second, pokeapiclient.WithBaseURL(server.URL))
 	configInput := &types.Config{
 		NEXT_URL: nil,
 		PREV_URL: nil,
 		Client:   clientInput,
 		Pokedex:  types.Pokedex{},
 	}
-	output, _ := utils.Catch(configInput, StdDependency{}, "pikachu")
+	output, err := utils.Catch(context.Background(), configInput, StdDependency{}, "pikachu")
+	if err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
 	pikachuInformation := output.Response().(types.PokemonInformation)
 	if pikachuInformation.Caught != false {
 		t.Fatalf(`Pokemon should not be caught`)
 	}
 
-	_, err := configInput.Pokedex.GetPokemon("pikachu")
+	_, err = configInput.Pokedex.GetPokemon("pikachu")
 	if err == nil {
 		t.Fatalf(`Pokedex did not store pikachu`)
 	}
 }
 func TestCatchCommandSuccessfulCatch(t *testing.T) {
-	clientInput := pokeapiclient.NewClient(50000, 10000)
+	server := newFixtureServer(t)
+	clientInput := newTestClient(t, 5*time.Second, pokeapiclient.WithBaseURL(server.URL))
 	configInput := &types.Config{
 		NEXT_URL: nil,
 		PREV_URL: nil,
 		Client:   clientInput,
 		Pokedex:  types.Pokedex{},
 	}
-	output, _ := utils.Catch(configInput, PassDependency{}, "pikachu")
+	output, err := utils.Catch(context.Background(), configInput, PassDependency{}, "pikachu")
+	if err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
 	pikachuInformation := output.Response().(types.PokemonInformation)
 	if pikachuInformation.Caught != true {
 		t.Fatalf(`Pokemon should be caught`)
 	}
 
-	_, err := configInput.Pokedex.GetPokemon("pikachu")
+	_, err = configInput.Pokedex.GetPokemon("pikachu")
 	if err != nil {
 		t.Fatalf(`Pokedex did not store pikachu`)
 	}
 
 }
 
+func TestCatchWithoutBaseExperience(t *testing.T) {
+	server := newFixtureServer(t)
+	clientInput := newTestClient(t, 5*time.Second, pokeapiclient.WithBaseURL(server.URL))
+	configInput := &types.Config{Client: clientInput, Pokedex: types.Pokedex{}}
+
+	// eternatus-eternamax has no base_experience in PokeAPI.
+	output, err := utils.Catch(context.Background(), configInput, FailDependency{}, "eternatus-eternamax")
+	if err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	if output.Response().(types.PokemonInformation).Caught {
+		t.Fatalf(`Pokemon should not be caught`)
+	}
+	output, err = utils.Catch(context.Background(), configInput, PassDependency{}, "eternatus-eternamax")
+	if err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	if !output.Response().(types.PokemonInformation).Caught {
+		t.Fatalf(`Pokemon should be caught`)
+	}
+}
+
 func TestInspectCommand(t *testing.T) {
-	clientInput := pokeapiclient.NewClient(50000, 10000)
+	server := newFixtureServer(t)
+	clientInput := newTestClient(t, 5*time.Second, pokeapiclient.WithBaseURL(server.URL))
 	configInput := &types.Config{
 		NEXT_URL: nil,
 		PREV_URL: nil,
 		Client:   clientInput,
 		Pokedex:  types.Pokedex{},
 	}
-	utils.Catch(configInput, PassDependency{}, "pikachu")
-	output, _ := utils.Inspect(configInput, StdDependency{}, "pikachu")
-	pikachuInformation := output.Response().(types.PokemonInformation)
+	utils.Catch(context.Background(), configInput, PassDependency{}, "pikachu")
+	output, err := utils.Inspect(context.Background(), configInput, StdDependency{}, "pikachu")
+	if err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	pikachuInformation := output.Response().(types.CaughtPokemon)
 	if pikachuInformation.Name != "pikachu" {
 		t.Fatalf(`Pokemon should be pikachu`)
 	}
 
 }
 func TestPokedexCommand(t *testing.T) {
-	clientInput := pokeapiclient.NewClient(50000, 10000)
+	server := newFixtureServer(t)
+	clientInput := newTestClient(t, 5*time.Second, pokeapiclient.WithBaseURL(server.URL))
 	configInput := &types.Config{
 		NEXT_URL: nil,
 		PREV_URL: nil,
 		Client:   clientInput,
 		Pokedex:  types.Pokedex{},
 	}
-	utils.Catch(configInput, PassDependency{}, "pikachu")
-	output, _ := utils.Pokedex(configInput, StdDependency{}, "")
+	utils.Catch(context.Background(), configInput, PassDependency{}, "pikachu")
+	output, err := utils.Pokedex(context.Background(), configInput, StdDependency{}, "")
+	if err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
 	pokedex := output.Response().(types.Pokedex)
 	pokemon, _ := pokedex.GetPokemon("pikachu")
 	if pokemon.Name != "pikachu" {
@@ -294,6 +627,247 @@ func TestPokedexCommand(t *testing.T) {
 	}
 
 }
+func TestEndpointUsesBaseURL(t *testing.T) {
+	client := newTestClient(t, 5*time.Second, pokeapiclient.WithBaseURL("http://mirror.local/api/v2/"))
+	url := client.Endpoint("pokemon/%s", "pikachu")
+	if url != "http://mirror.local/api/v2/pokemon/pikachu" {
+		t.Fatalf(`Endpoint returned %v`, url)
+	}
+	escapes := map[string]string{
+		"../location": "http://mirror.local/api/v2/pokemon/..%2Flocation",
+		"..":          "http://mirror.local/api/v2/pokemon/%2E%2E",
+		"pika%zz":     "http://mirror.local/api/v2/pokemon/pika%25zz",
+		"mr mime?#":   "http://mirror.local/api/v2/pokemon/mr%20mime%3F%23",
+	}
+	for name, want := range escapes {
+		if url := client.Endpoint("pokemon/%s", name); url != want {
+			t.Fatalf(`Endpoint should escape %q as %v but returned %v`, name, want, url)
+		}
+	}
+	defaultClient := newTestClient(t, 5*time.Second)
+	if defaultClient.BaseURL != pokeapiclient.DefaultBaseURL {
+		t.Fatalf(`BaseURL should default to %v but was %v`, pokeapiclient.DefaultBaseURL, defaultClient.BaseURL)
+	}
+}
+
+func TestClientGetPokemon(t *testing.T) {
+	server := newFixtureServer(t)
+	client := newTestClient(t, 5*time.Second, pokeapiclient.WithBaseURL(server.URL))
+	pokemon, err := client.GetPokemon(context.Background(), "pikachu")
+	if err != nil {
+		t.Fatalf("Error object should be nil but was: %s", err.Error())
+	}
+	if pokemon.ID != 25 || pokemon.BaseExperience != 112 {
+		t.Fatalf(`GetPokemon decoded id %v and base experience %v`, pokemon.ID, pokemon.BaseExperience)
+	}
+	if _, exists := client.Cache.Get(server.URL + "/pokemon/pikachu"); !exists {
+		t.Fatalf(`GetPokemon did not cache the response`)
+	}
+}
+
+func TestClientGetLocationAreaNotFound(t *testing.T) {
+	server := newFixtureServer(t)
+	client := newTestClient(t, 5*time.Second, pokeapiclient.WithBaseURL(server.URL))
+	_, err := client.GetLocationArea(context.Background(), "nowhere")
+	var notFound *pokeapiclient.NotFoundError
+	if !errors.As(err, &notFound) {
+		t.Fatalf("Error should be a NotFoundError but was: %v", err)
+	}
+	if client.Cache.Len() != 0 {
+		t.Fatalf(`Cache should be empty after a 404 but had %v entries`, client.Cache.Len())
+	}
+}
+
+func TestClientCoalescesConcurrentMisses(t *testing.T) {
+	var hits int32
+	fixtures := newFixtureServer(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		time.Sleep(20 * time.Millisecond)
+		http.Redirect(w, r, fixtures.URL+r.URL.Path, http.StatusTemporaryRedirect)
+	}))
+	defer server.Close()
+	client := newTestClient(t, 5*time.Second, pokeapiclient.WithBaseURL(server.URL), pokeapiclient.WithRateLimit(0, 0))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pokemon, err := client.GetPokemon(context.Background(), "pikachu")
+			if err != nil || pokemon.Name != "pikachu" {
+				t.Errorf("Expected pikachu but got %v and error %v", pokemon.Name, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if atomic.LoadInt32(&hits) != 1 {
+		t.Fatalf(`Concurrent callers should share one request but the server was hit %v times`, hits)
+	}
+	if client.Cache.Len() != 1 {
+		t.Fatalf(`Cache length should be 1 but was %v`, client.Cache.Len())
+	}
+}
+
+func TestClientStaleWhileRevalidate(t *testing.T) {
+	var hits, conditional int32
+	fixtures := newFixtureServer(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&conditional, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		response, err := http.Get(fixtures.URL + r.URL.Path)
+		if err != nil {
+			t.Errorf("Error object should be nil but was: %s", err.Error())
+			return
+		}
+		defer response.Body.Close()
+		io.Copy(w, response.Body)
+	}))
+	defer server.Close()
+	client := newTestClient(t, 5*time.Second,
+		pokeapiclient.WithBaseURL(server.URL),
+		pokeapiclient.WithCacheTTLs(pokeapiclient.CacheTTLs{Pokemon: 5 * time.Millisecond}),
+		pokeapiclient.WithStaleWhileRevalidate(time.Hour),
+	)
+
+	client.GetPokemon(context.Background(), "pikachu")
+	time.Sleep(10 * time.Millisecond)
+
+	pokemon, err := client.GetPokemon(context.Background(), "pikachu")
+	if err != nil || pokemon.Name != "pikachu" {
+		t.Fatalf("Expected the stale pikachu but got %v and error %v", pokemon.Name, err)
+	}
+	client.Close()
+
+	if atomic.LoadInt32(&hits) != 2 || atomic.LoadInt32(&conditional) != 1 {
+		t.Fatalf(`Expected 2 requests, 1 of them conditional, but got %v and %v`, hits, conditional)
+	}
+	if _, _, fresh, ok := client.Cache.Lookup(server.URL + "/pokemon/pikachu"); !ok || !fresh {
+		t.Fatalf(`A 304 should make the cached entry fresh again`)
+	}
+	if stats := client.Cache.(pokeapiclient.InspectableCache).Stats(); stats.StaleHits != 1 {
+		t.Fatalf(`Expected 1 stale hit but got %v`, stats.StaleHits)
+	}
+}
+
+func TestClientWithNoopCache(t *testing.T) {
+	var hits int32
+	fixtures := newFixtureServer(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		http.Redirect(w, r, fixtures.URL+r.URL.Path, http.StatusTemporaryRedirect)
+	}))
+	defer server.Close()
+	client := newTestClient(t, 5*time.Second, pokeapiclient.WithBaseURL(server.URL), pokeapiclient.WithCache(pokecache.NoopCache{}))
+
+	client.GetPokemon(context.Background(), "pikachu")
+	pokemon, err := client.GetPokemon(context.Background(), "pikachu")
+	if err != nil || pokemon.Name != "pikachu" {
+		t.Fatalf("Expected pikachu but got %v and error %v", pokemon.Name, err)
+	}
+	if atomic.LoadInt32(&hits) != 2 || client.Cache.Len() != 0 {
+		t.Fatalf(`A no-op cache should store nothing, so both calls hit the server`)
+	}
+
+	configInput := &types.Config{Client: client, Pokedex: types.Pokedex{}}
+	output, err := utils.Cache(context.Background(), configInput, StdDependency{}, "stats")
+	if err != nil || output.Response() != "This cache does not keep any statistics" {
+		t.Fatalf(`The cache command should explain that there are no statistics`)
+	}
+}
+
+func TestClientTypedErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/pokemon/broken" {
+			w.Write([]byte("not json"))
+			return
+		}
+		http.Error(w, "upstream exploded", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	client := newTestClient(t, 5*time.Second, pokeapiclient.WithBaseURL(server.URL), pokeapiclient.WithRetryPolicy(pokeapiclient.NoRetry))
+
+	_, err := client.GetPokemon(context.Background(), "pikachu")
+	var statusErr *pokeapiclient.UpstreamStatusError
+	if !errors.As(err, &statusErr) || statusErr.Code != http.StatusInternalServerError {
+		t.Fatalf("Error should be an UpstreamStatusError with code 500 but was: %v", err)
+	}
+
+	_, err = client.GetPokemon(context.Background(), "broken")
+	var decodeErr *pokeapiclient.DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("Error should be a DecodeError but was: %v", err)
+	}
+
+	server.Close()
+	_, err = client.GetPokemon(context.Background(), "pikachu")
+	var networkErr *pokeapiclient.NetworkError
+	if !errors.As(err, &networkErr) {
+		t.Fatalf("Error should be a NetworkError but was: %v", err)
+	}
+}
+
+func TestClientTimeoutAndCancel(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := newTestClient(t, 20*time.Millisecond, pokeapiclient.WithBaseURL(server.URL), pokeapiclient.WithRetryPolicy(pokeapiclient.NoRetry))
+	_, err := client.GetPokemon(context.Background(), "pikachu")
+	var networkErr *pokeapiclient.NetworkError
+	if !errors.As(err, &networkErr) {
+		t.Fatalf("Error should be a NetworkError after the timeout but was: %v", err)
+	}
+
+	client = newTestClient(t, 5*time.Second, pokeapiclient.WithBaseURL(server.URL))
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	_, err = client.GetPokemon(ctx, "pikachu")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Error should wrap context.Canceled but was: %v", err)
+	}
+}
+
+// newTestClient builds a client that is closed when the test ends, so its
+// cache reaper does not outlive the test.
+func newTestClient(t *testing.T, timeout time.Duration, opts ...pokeapiclient.Option) *pokeapiclient.Client {
+	t.Helper()
+	client := pokeapiclient.NewClient(timeout, time.Hour, opts...)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// newFixtureServer serves the JSON files under testdata using the PokeAPI
+// URL layout, so /pokemon/pikachu is answered from testdata/pokemon/pikachu.json.
+func newFixtureServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.Trim(r.URL.Path, "/")
+		body, err := os.ReadFile(filepath.Join("testdata", filepath.FromSlash(path)+".json"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(strings.ReplaceAll(string(body), "{{BASE_URL}}", server.URL)))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
 func contains(slice []string, value string) bool {
 	for _, item := range slice {
 		if item == value {
@@ -0,0 +1,80 @@
+package types
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mdwiltfong/PokeDex/internal/pokeapiclient"
+)
+
+// CaughtPokemon is what the Pokedex keeps for each catch: the handful of
+// PokeAPI fields inspect shows, plus the details of the catch itself. The
+// full payload, with moves and sprites, is one Details call away.
+type CaughtPokemon struct {
+	// InstanceID tells apart pokemon of the same species in the box.
+	InstanceID     int         `json:"instance_id"`
+	ID             int         `json:"id"`
+	Name           string      `json:"name"`
+	Species        string      `json:"species"`
+	Types          []string    `json:"types"`
+	Stats          []StatValue `json:"stats"`
+	Abilities      []string    `json:"abilities"`
+	Height         int         `json:"height"`
+	Weight         int         `json:"weight"`
+	BaseExperience int         `json:"base_experience"`
+
+	CaughtAt       time.Time `json:"caught_at"`
+	CaughtLocation string    `json:"caught_location,omitempty"`
+	Nickname       string    `json:"nickname,omitempty"`
+	Level          int       `json:"level"`
+	// IVs are this pokemon's individual values, 0 to MaxIV, keyed by stat.
+	IVs map[string]int `json:"ivs"`
+}
+
+// MaxIV is the highest individual value a stat can have.
+const MaxIV = 31
+
+type StatValue struct {
+	Name string `json:"name"`
+	Base int    `json:"base"`
+}
+
+// NewCaughtPokemon keeps just the fields of info the Pokedex stores.
+func NewCaughtPokemon(info PokemonInformation) CaughtPokemon {
+	pokemon := CaughtPokemon{
+		ID:             info.ID,
+		Name:           info.Name,
+		Species:        info.Species.Name,
+		Types:          make([]string, 0, len(info.Types)),
+		Stats:          make([]StatValue, 0, len(info.Stats)),
+		Abilities:      make([]string, 0, len(info.Abilities)),
+		Height:         info.Height,
+		Weight:         info.Weight,
+		BaseExperience: info.BaseExperience,
+	}
+	for _, t := range info.Types {
+		pokemon.Types = append(pokemon.Types, t.Type.Name)
+	}
+	for _, stat := range info.Stats {
+		pokemon.Stats = append(pokemon.Stats, StatValue{Name: stat.Stat.Name, Base: stat.BaseStat})
+	}
+	for _, ability := range info.Abilities {
+		pokemon.Abilities = append(pokemon.Abilities, ability.Ability.Name)
+	}
+	return pokemon
+}
+
+// DisplayName is the nickname, if there is one, followed by the species.
+func (p CaughtPokemon) DisplayName() string {
+	if p.Nickname != "" {
+		return fmt.Sprintf("%s the %s", p.Nickname, p.Name)
+	}
+	return p.Name
+}
+
+// Details fetches the full PokeAPI payload, which usually comes straight
+// from the client's cache.
+func (p CaughtPokemon) Details(ctx context.Context, client *pokeapiclient.Client) (PokemonInformation, error) {
+	return client.GetPokemon(ctx, p.Name)
+}
@@ -0,0 +1,137 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PokedexFilter picks pokemon out of the box.
+type PokedexFilter func(CaughtPokemon) bool
+
+// ParseFilter parses a filter expression such as type:fire, stat:speed>100,
+// ability:levitate, generation:3, caught-after:2026-01-01 or name:pika. A
+// bare word is matched against names and nicknames, forgiving small typos.
+func ParseFilter(expr string) (PokedexFilter, error) {
+	key, value, found := strings.Cut(expr, ":")
+	if !found {
+		key, value = "name", expr
+	}
+	if value == "" {
+		return nil, fmt.Errorf("Filter %q needs a value", expr)
+	}
+	switch key {
+	case "name":
+		return func(p CaughtPokemon) bool {
+			return fuzzyMatch(value, p.Name) || (p.Nickname != "" && fuzzyMatch(value, strings.ToLower(p.Nickname)))
+		}, nil
+	case "type":
+		return func(p CaughtPokemon) bool { return containsString(p.Types, value) }, nil
+	case "ability":
+		return func(p CaughtPokemon) bool { return containsString(p.Abilities, value) }, nil
+	case "stat":
+		return parseStatFilter(value)
+	case "generation", "gen":
+		generation, err := parseGeneration(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(p CaughtPokemon) bool {
+			g, ok := GenerationOf(p.ID)
+			return ok && g.Number == generation.Number
+		}, nil
+	case "caught-after", "caught-before":
+		at, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a date like 2026-01-31", value)
+		}
+		if key == "caught-after" {
+			return func(p CaughtPokemon) bool { return !p.CaughtAt.Before(at) }, nil
+		}
+		return func(p CaughtPokemon) bool { return p.CaughtAt.Before(at) }, nil
+	}
+	return nil, fmt.Errorf("Unknown filter %q: use name, type, ability, stat, generation, caught-after or caught-before", key)
+}
+
+// statOperators are tried longest first so >= is not read as >.
+var statOperators = []string{">=", "<=", ">", "<", "="}
+
+// parseStatFilter parses a comparison such as speed>100 against base stats.
+func parseStatFilter(value string) (PokedexFilter, error) {
+	for _, op := range statOperators {
+		name, number, found := strings.Cut(value, op)
+		if !found {
+			continue
+		}
+		limit, err := strconv.Atoi(number)
+		if name == "" || err != nil {
+			break
+		}
+		compare := map[string]func(int) bool{
+			">=": func(base int) bool { return base >= limit },
+			"<=": func(base int) bool { return base <= limit },
+			">":  func(base int) bool { return base > limit },
+			"<":  func(base int) bool { return base < limit },
+			"=":  func(base int) bool { return base == limit },
+		}[op]
+		return func(p CaughtPokemon) bool {
+			for _, stat := range p.Stats {
+				if stat.Name == name {
+					return compare(stat.Base)
+				}
+			}
+			return false
+		}, nil
+	}
+	return nil, fmt.Errorf("%q is not a stat comparison like speed>100", value)
+}
+
+// parseGeneration accepts 3, iii or generation-iii.
+func parseGeneration(value string) (Generation, error) {
+	for _, generation := range Generations {
+		if value == strconv.Itoa(generation.Number) || value == generation.Name || "generation-"+value == generation.Name {
+			return generation, nil
+		}
+	}
+	return Generation{}, fmt.Errorf("%q is not a generation: use 1 to %d", value, len(Generations))
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// fuzzyMatch reports whether query is part of name or, for queries long
+// enough to tell apart, a near miss of it.
+func fuzzyMatch(query, name string) bool {
+	if strings.Contains(name, query) {
+		return true
+	}
+	return len(query) >= 4 && editDistance(query, name) <= len(query)/4
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
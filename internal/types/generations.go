@@ -0,0 +1,51 @@
+package types
+
+// Generation is a range of national dex numbers introduced by one
+// generation of games.
+type Generation struct {
+	Number int
+	Name   string
+	First  int
+	Last   int
+}
+
+// Total is how many species the generation introduced.
+func (g Generation) Total() int {
+	return g.Last - g.First + 1
+}
+
+// Generations lists every generation in order. Alternate forms have IDs
+// above 10000 in PokeAPI and do not belong to any of them.
+var Generations = []Generation{
+	{Number: 1, Name: "generation-i", First: 1, Last: 151},
+	{Number: 2, Name: "generation-ii", First: 152, Last: 251},
+	{Number: 3, Name: "generation-iii", First: 252, Last: 386},
+	{Number: 4, Name: "generation-iv", First: 387, Last: 493},
+	{Number: 5, Name: "generation-v", First: 494, Last: 649},
+	{Number: 6, Name: "generation-vi", First: 650, Last: 721},
+	{Number: 7, Name: "generation-vii", First: 722, Last: 809},
+	{Number: 8, Name: "generation-viii", First: 810, Last: 905},
+	{Number: 9, Name: "generation-ix", First: 906, Last: 1025},
+}
+
+// GenerationOf returns the generation that introduced the species with the
+// given national dex number.
+func GenerationOf(id int) (Generation, bool) {
+	for _, generation := range Generations {
+		if id >= generation.First && id <= generation.Last {
+			return generation, true
+		}
+	}
+	return Generation{}, false
+}
+
+type GenerationProgress struct {
+	Generation Generation
+	Seen       int
+	Caught     int
+}
+
+// Completion is the percentage of the generation caught.
+func (g GenerationProgress) Completion() float64 {
+	return 100 * float64(g.Caught) / float64(g.Generation.Total())
+}
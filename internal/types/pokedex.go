@@ -0,0 +1,306 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Pokedex has two halves, like the in-game one: Species records every
+// species the trainer has seen or caught, and Box holds the individual
+// pokemon they own, so the same species can be caught more than once.
+type Pokedex struct {
+	Species map[string]SpeciesEntry `json:"species"`
+	// Box is in catch order.
+	Box []CaughtPokemon `json:"box"`
+	// NextID is the instance ID the next catch gets.
+	NextID int `json:"next_id"`
+	// Releases is the history of pokemon released from the box, oldest first.
+	Releases []ReleaseRecord `json:"releases,omitempty"`
+}
+
+// SpeciesEntry is the dex record of a species. It is created the first time
+// the species is seen and kept even after every pokemon of that species has
+// left the box.
+type SpeciesEntry struct {
+	ID                int       `json:"id"`
+	Name              string    `json:"name"`
+	FirstSeenAt       time.Time `json:"first_seen_at"`
+	FirstSeenLocation string    `json:"first_seen_location,omitempty"`
+	LastSeenAt        time.Time `json:"last_seen_at"`
+	LastSeenLocation  string    `json:"last_seen_location,omitempty"`
+	TimesCaught       int       `json:"times_caught"`
+	FirstCaughtAt     time.Time `json:"first_caught_at"`
+}
+
+// Caught reports whether the species has ever been caught.
+func (e SpeciesEntry) Caught() bool {
+	return e.TimesCaught > 0
+}
+
+// See records that a species was seen at location. id is its national dex
+// number, or 0 if it is not known.
+func (p *Pokedex) See(id int, name, location string, at time.Time) {
+	if p.Species == nil {
+		p.Species = map[string]SpeciesEntry{}
+	}
+	entry, exists := p.Species[name]
+	if !exists {
+		entry = SpeciesEntry{Name: name, FirstSeenAt: at, FirstSeenLocation: location}
+	}
+	if entry.ID == 0 {
+		entry.ID = id
+	}
+	entry.LastSeenAt = at
+	entry.LastSeenLocation = location
+	p.Species[name] = entry
+}
+
+// AddPokemon puts a new catch in the box, registers its species as seen and
+// caught and returns it with its instance ID filled in.
+func (p *Pokedex) AddPokemon(pokemon CaughtPokemon) CaughtPokemon {
+	if p.NextID <= 0 {
+		p.NextID = 1
+	}
+	pokemon.InstanceID = p.NextID
+	p.NextID++
+	p.Box = append(p.Box, pokemon)
+
+	p.See(pokemon.ID, pokemon.Name, pokemon.CaughtLocation, pokemon.CaughtAt)
+	entry := p.Species[pokemon.Name]
+	if !entry.Caught() {
+		entry.FirstCaughtAt = pokemon.CaughtAt
+	}
+	entry.TimesCaught++
+	p.Species[pokemon.Name] = entry
+	return pokemon
+}
+
+// GetPokemon finds a pokemon in the box by instance ID (as in 3 or #3), the
+// first one caught of the named species or, failing that, by nickname.
+func (p *Pokedex) GetPokemon(name string) (CaughtPokemon, error) {
+	if i := p.index(name); i >= 0 {
+		return p.Box[i], nil
+	}
+	return CaughtPokemon{}, errors.New("Pokemon not found")
+}
+
+// index returns the box position of the pokemon GetPokemon would find, or -1.
+func (p *Pokedex) index(name string) int {
+	if id, err := strconv.Atoi(strings.TrimPrefix(name, "#")); err == nil {
+		for i, pokemon := range p.Box {
+			if pokemon.InstanceID == id {
+				return i
+			}
+		}
+		return -1
+	}
+	for i, pokemon := range p.Box {
+		if pokemon.Name == name {
+			return i
+		}
+	}
+	for i, pokemon := range p.Box {
+		if pokemon.Nickname != "" && strings.EqualFold(pokemon.Nickname, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Len returns how many pokemon are in the box.
+func (p *Pokedex) Len() int {
+	return len(p.Box)
+}
+
+// HasCaught reports whether a species was ever caught.
+func (p *Pokedex) HasCaught(name string) bool {
+	return p.Species[name].Caught()
+}
+
+// HasSeen reports whether a species was ever seen, including by catching it.
+func (p *Pokedex) HasSeen(name string) bool {
+	_, exists := p.Species[name]
+	return exists
+}
+
+// Progress counts the species seen and caught in each generation.
+func (p *Pokedex) Progress() []GenerationProgress {
+	progress := make([]GenerationProgress, len(Generations))
+	for i, generation := range Generations {
+		progress[i].Generation = generation
+	}
+	for _, entry := range p.Species {
+		generation, ok := GenerationOf(entry.ID)
+		if !ok {
+			continue
+		}
+		progress[generation.Number-1].Seen++
+		if entry.Caught() {
+			progress[generation.Number-1].Caught++
+		}
+	}
+	return progress
+}
+
+// ReleaseRecord is an entry in the Pokedex's release history.
+type ReleaseRecord struct {
+	InstanceID int       `json:"instance_id"`
+	Name       string    `json:"name"`
+	Nickname   string    `json:"nickname,omitempty"`
+	Level      int       `json:"level"`
+	CaughtAt   time.Time `json:"caught_at"`
+	ReleasedAt time.Time `json:"released_at"`
+}
+
+// Matching returns every pokemon in the box with the given instance ID (as
+// in 3 or #3) or of the named species.
+func (p *Pokedex) Matching(name string) []CaughtPokemon {
+	if _, err := strconv.Atoi(strings.TrimPrefix(name, "#")); err == nil {
+		if i := p.index(name); i >= 0 {
+			return []CaughtPokemon{p.Box[i]}
+		}
+		return nil
+	}
+	var matches []CaughtPokemon
+	for _, pokemon := range p.Box {
+		if pokemon.Name == name {
+			matches = append(matches, pokemon)
+		}
+	}
+	if matches == nil {
+		if i := p.index(name); i >= 0 {
+			matches = append(matches, p.Box[i])
+		}
+	}
+	return matches
+}
+
+// SetNickname names a pokemon in the box; an empty nickname removes it.
+// Nicknames are unique within the box, ignoring case, so they can be used
+// to address a pokemon.
+func (p *Pokedex) SetNickname(instanceID int, nickname string) error {
+	i := p.index(strconv.Itoa(instanceID))
+	if i < 0 {
+		return errors.New("Pokemon not found")
+	}
+	if nickname != "" {
+		if err := ValidateNickname(nickname); err != nil {
+			return err
+		}
+		for _, pokemon := range p.Box {
+			if pokemon.InstanceID != instanceID && strings.EqualFold(pokemon.Nickname, nickname) {
+				return fmt.Errorf("#%d is already called %s", pokemon.InstanceID, pokemon.Nickname)
+			}
+		}
+	}
+	p.Box[i].Nickname = nickname
+	return nil
+}
+
+// ValidateNickname rejects nicknames that could not be used to find the
+// pokemon again.
+func ValidateNickname(nickname string) error {
+	if strings.ContainsAny(nickname, " \t") {
+		return errors.New("Nicknames can't contain spaces")
+	}
+	if _, err := strconv.Atoi(strings.TrimPrefix(nickname, "#")); err == nil {
+		return errors.New("Nicknames can't be numbers, those are used for box numbers")
+	}
+	if len(nickname) > 20 {
+		return errors.New("Nicknames can be at most 20 characters long")
+	}
+	return nil
+}
+
+// Release takes a pokemon out of the box and records it in the release
+// history. Its species stays registered as caught.
+func (p *Pokedex) Release(instanceID int, at time.Time) (ReleaseRecord, error) {
+	i := p.index(strconv.Itoa(instanceID))
+	if i < 0 {
+		return ReleaseRecord{}, errors.New("Pokemon not found")
+	}
+	pokemon := p.Box[i]
+	p.Box = append(p.Box[:i:i], p.Box[i+1:]...)
+	record := ReleaseRecord{
+		InstanceID: pokemon.InstanceID,
+		Name:       pokemon.Name,
+		Nickname:   pokemon.Nickname,
+		Level:      pokemon.Level,
+		CaughtAt:   pokemon.CaughtAt,
+		ReleasedAt: at,
+	}
+	p.Releases = append(p.Releases, record)
+	return record, nil
+}
+
+func (r ReleaseRecord) displayName() string {
+	return CaughtPokemon{Name: r.Name, Nickname: r.Nickname}.DisplayName()
+}
+
+// PokedexSort names an order for listing the box.
+type PokedexSort string
+
+const (
+	// SortByDex orders by national dex number, the default.
+	SortByDex     PokedexSort = "dex"
+	SortByName    PokedexSort = "name"
+	SortByCaught  PokedexSort = "caught"
+	SortByExp     PokedexSort = "exp"
+	SortByType    PokedexSort = "type"
+	defaultSortBy             = SortByDex
+)
+
+// PokedexSorts lists every supported order.
+var PokedexSorts = []PokedexSort{SortByDex, SortByName, SortByCaught, SortByExp, SortByType}
+
+// Sorted returns the box in the given order, reversed if asked. Ties are
+// broken by dex number and then by catch order.
+func (p *Pokedex) Sorted(by PokedexSort, reverse bool) ([]CaughtPokemon, error) {
+	if by == "" {
+		by = defaultSortBy
+	}
+	var less func(a, b CaughtPokemon) bool
+	switch by {
+	case SortByDex:
+		less = func(a, b CaughtPokemon) bool { return false }
+	case SortByName:
+		less = func(a, b CaughtPokemon) bool { return a.Name < b.Name }
+	case SortByCaught:
+		less = func(a, b CaughtPokemon) bool { return a.CaughtAt.Before(b.CaughtAt) }
+	case SortByExp:
+		less = func(a, b CaughtPokemon) bool { return a.BaseExperience < b.BaseExperience }
+	case SortByType:
+		less = func(a, b CaughtPokemon) bool { return primaryType(a) < primaryType(b) }
+	default:
+		return nil, fmt.Errorf("Unknown sort order %q", by)
+	}
+	sorted := append([]CaughtPokemon(nil), p.Box...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if less(a, b) {
+			return !reverse
+		}
+		if less(b, a) {
+			return reverse
+		}
+		if reverse && by == SortByDex {
+			a, b = b, a
+		}
+		if a.ID != b.ID {
+			return a.ID < b.ID
+		}
+		return a.InstanceID < b.InstanceID
+	})
+	return sorted, nil
+}
+
+func primaryType(p CaughtPokemon) string {
+	if len(p.Types) == 0 {
+		return ""
+	}
+	return p.Types[0]
+}
@@ -0,0 +1,154 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultProfile is the trainer used when none is chosen.
+const DefaultProfile = "default"
+
+var profileName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// ProfileStore keeps one save file per trainer profile in Dir.
+type ProfileStore struct {
+	Dir string
+}
+
+type ProfileSummary struct {
+	Name    string
+	Caught  int
+	SavedAt time.Time
+}
+
+// DefaultProfilesDir is where profiles live unless told otherwise.
+func DefaultProfilesDir() (string, error) {
+	dir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "pokedex", "profiles"), nil
+}
+
+// LegacySavePath is where the Pokedex was saved before there were profiles.
+func LegacySavePath() (string, error) {
+	dir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "pokedex", "pokedex.json"), nil
+}
+
+// ImportLegacySave copies a save from before there were profiles into the
+// default profile, unless that profile has been saved already. The old file
+// is left where it is. It reports whether anything was imported.
+func (s ProfileStore) ImportLegacySave(legacyPath string) (bool, error) {
+	if _, err := os.Stat(s.Path(DefaultProfile)); !errors.Is(err, fs.ErrNotExist) {
+		return false, err
+	}
+	if _, err := os.Stat(legacyPath); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	save, err := ReadSaveFile(legacyPath)
+	if err != nil {
+		return false, err
+	}
+	if err := WriteSaveFile(s.Path(DefaultProfile), save); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ValidateProfileName rejects names that would not make a safe file name.
+func ValidateProfileName(name string) error {
+	if !profileName.MatchString(name) {
+		return fmt.Errorf("%q is not a valid profile name: use lowercase letters, digits, - and _", name)
+	}
+	return nil
+}
+
+// Path returns the save file for the named profile.
+func (s ProfileStore) Path(name string) string {
+	return filepath.Join(s.Dir, name+".json")
+}
+
+// List summarises every saved profile, sorted by name.
+func (s ProfileStore) List() ([]ProfileSummary, error) {
+	files, err := os.ReadDir(s.Dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []ProfileSummary{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	summaries := []ProfileSummary{}
+	for _, file := range files {
+		name, ok := strings.CutSuffix(file.Name(), ".json")
+		if file.IsDir() || !ok || ValidateProfileName(name) != nil {
+			continue
+		}
+		save, err := ReadSaveFile(s.Path(name))
+		if err != nil {
+			continue
+		}
+		summaries = append(summaries, ProfileSummary{Name: name, Caught: save.Pokedex.Len(), SavedAt: save.SavedAt})
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Name < summaries[j].Name
+	})
+	return summaries, nil
+}
+
+// SaveFile captures what Config persists for the current profile.
+func (c *Config) SaveFile() SaveFile {
+	return SaveFile{
+		Pokedex:         c.Pokedex,
+		CurrentLocation: c.CurrentLocation,
+		Settings:        c.Settings,
+	}
+}
+
+// ApplySaveFile replaces the Pokedex and settings with those in save.
+func (c *Config) ApplySaveFile(save SaveFile) {
+	c.Pokedex = save.Pokedex
+	c.Settings = save.Settings
+	c.CurrentLocation = save.CurrentLocation
+}
+
+// SwitchProfile saves the current profile, if it is being saved at all, and
+// loads the named one in its place. A profile that has never been saved
+// starts out empty.
+func (c *Config) SwitchProfile(name string) error {
+	if c.Profiles.Dir == "" {
+		return errors.New("Profiles are disabled for this session")
+	}
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+	path := c.Profiles.Path(name)
+	save, err := ReadSaveFile(path)
+	if err != nil {
+		return err
+	}
+	if c.SavePath != "" {
+		if err := WriteSaveFile(c.SavePath, c.SaveFile()); err != nil {
+			return fmt.Errorf("Could not save profile %s: %w", c.Profile, err)
+		}
+	}
+	c.ApplySaveFile(save)
+	c.Profile = name
+	c.SavePath = path
+	c.PREV_URL = nil
+	c.NEXT_URL = nil
+	return nil
+}
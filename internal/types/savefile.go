@@ -0,0 +1,198 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"time"
+)
+
+// SaveFileVersion is bumped whenever the save file layout changes, so
+// ReadSaveFile can tell old files from ones written by a newer build.
+//
+//  1. the Pokedex, as full PokeAPI payloads
+//  2. adds Settings
+//  3. stores each catch as a CaughtPokemon
+//  4. splits the Pokedex into species and a box of individual pokemon
+//  5. records species that were seen but not caught
+//  6. moves CurrentLocation out of Settings
+const SaveFileVersion = 6
+
+type SaveFile struct {
+	Version int       `json:"version"`
+	SavedAt time.Time `json:"saved_at"`
+	Pokedex Pokedex   `json:"pokedex"`
+	// CurrentLocation is the area the trainer last explored, so catches
+	// after a restart are still recorded in the right place.
+	CurrentLocation string          `json:"current_location,omitempty"`
+	Settings        ProfileSettings `json:"settings"`
+}
+
+// ProfileSettings are the per-trainer preferences kept alongside the Pokedex.
+type ProfileSettings struct {
+	// PokedexSort is the order pokedex lists the box in when it isn't given
+	// a sort: argument, written the same way, e.g. "-exp". Empty means dex
+	// order.
+	PokedexSort string `json:"pokedex_sort,omitempty"`
+	// PokedexColumns lists the box in columns unless pokedex is asked for
+	// a list.
+	PokedexColumns bool `json:"pokedex_columns,omitempty"`
+}
+
+// DataDir returns the per-user directory for application data:
+// $XDG_DATA_HOME or ~/.local/share on Linux, and the platform's equivalent
+// elsewhere.
+func DataDir() (string, error) {
+	switch runtime.GOOS {
+	case "windows", "darwin", "ios":
+		return os.UserConfigDir()
+	}
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share"), nil
+}
+
+// LoadPokedex reads just the Pokedex from a save file. A missing file is not
+// an error; it just means nothing has been caught yet.
+func LoadPokedex(path string) (Pokedex, error) {
+	save, err := ReadSaveFile(path)
+	return save.Pokedex, err
+}
+
+// WriteSaveFile writes save to path, replacing the previous save only once
+// the new one is completely written.
+func WriteSaveFile(path string, save SaveFile) error {
+	save.Version = SaveFileVersion
+	save.SavedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(save, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// ReadSaveFile reads a file written by WriteSaveFile. A missing file reads
+// as an empty save.
+func ReadSaveFile(path string) (SaveFile, error) {
+	save := SaveFile{Version: SaveFileVersion, Pokedex: Pokedex{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return save, nil
+	}
+	if err != nil {
+		return SaveFile{}, err
+	}
+	var raw struct {
+		Version         int             `json:"version"`
+		SavedAt         time.Time       `json:"saved_at"`
+		Pokedex         json.RawMessage `json:"pokedex"`
+		CurrentLocation string          `json:"current_location"`
+		Settings        struct {
+			ProfileSettings
+			// CurrentLocation was kept here before save format 6.
+			CurrentLocation string `json:"current_location"`
+		} `json:"settings"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return SaveFile{}, fmt.Errorf("%s is not a Pokedex save file: %w", path, err)
+	}
+	if raw.Version > SaveFileVersion {
+		return SaveFile{}, fmt.Errorf("%s was saved by a newer version (save format %d, this build reads up to %d)", path, raw.Version, SaveFileVersion)
+	}
+	save.SavedAt, save.CurrentLocation, save.Settings = raw.SavedAt, raw.CurrentLocation, raw.Settings.ProfileSettings
+	if raw.Version < 6 {
+		save.CurrentLocation = raw.Settings.CurrentLocation
+	}
+	if len(raw.Pokedex) > 0 {
+		if err := decodePokedex(raw.Version, raw.Pokedex, &save.Pokedex); err != nil {
+			return SaveFile{}, fmt.Errorf("%s is not a Pokedex save file: %w", path, err)
+		}
+	}
+	return save, nil
+}
+
+// legacyPokemon is how catches were stored before save format 3.
+type legacyPokemon struct {
+	PokemonInformation
+	CaughtAt       time.Time `json:"caught_at"`
+	CaughtLocation string    `json:"caught_location"`
+}
+
+// decodePokedex decodes the Pokedex of a save file of the given version,
+// migrating older layouts.
+func decodePokedex(version int, data json.RawMessage, pokedex *Pokedex) error {
+	if version >= 5 {
+		return json.Unmarshal(data, pokedex)
+	}
+	if version == 4 {
+		if err := json.Unmarshal(data, pokedex); err != nil {
+			return err
+		}
+		// Every species was caught, which is when it was first seen.
+		for name, entry := range pokedex.Species {
+			entry.FirstSeenAt = entry.FirstCaughtAt
+			entry.LastSeenAt = entry.FirstCaughtAt
+			pokedex.Species[name] = entry
+		}
+		return nil
+	}
+	// Before format 4 the Pokedex was a map from name to the one pokemon
+	// of that species the trainer could own.
+	var caught []CaughtPokemon
+	if version == 3 {
+		var byName map[string]CaughtPokemon
+		if err := json.Unmarshal(data, &byName); err != nil {
+			return err
+		}
+		for _, pokemon := range byName {
+			caught = append(caught, pokemon)
+		}
+	} else {
+		var legacy map[string]legacyPokemon
+		if err := json.Unmarshal(data, &legacy); err != nil {
+			return err
+		}
+		for _, old := range legacy {
+			pokemon := NewCaughtPokemon(old.PokemonInformation)
+			pokemon.CaughtAt = old.CaughtAt
+			pokemon.CaughtLocation = old.CaughtLocation
+			caught = append(caught, pokemon)
+		}
+	}
+	sort.Slice(caught, func(i, j int) bool {
+		if !caught[i].CaughtAt.Equal(caught[j].CaughtAt) {
+			return caught[i].CaughtAt.Before(caught[j].CaughtAt)
+		}
+		return caught[i].Name < caught[j].Name
+	})
+	for _, pokemon := range caught {
+		pokedex.AddPokemon(pokemon)
+	}
+	return nil
+}
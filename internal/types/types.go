@@ -1,10 +1,13 @@
 package types
 
 import (
-	"errors"
+	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/mdwiltfong/PokeDex/internal/pokeapiclient"
+	"github.com/mdwiltfong/PokeDex/internal/pokecache"
 )
 
 type Config struct {
@@ -12,109 +15,97 @@ type Config struct {
 	NEXT_URL *string
 	Client   *pokeapiclient.Client
 	Pokedex  Pokedex
+	// SavePath is where save and autosave write the Pokedex; empty
+	// disables autosave.
+	SavePath string
+	// Confirm asks the trainer a yes or no question. It answers no if ctx
+	// is cancelled while waiting. Without it, anything that needs
+	// confirming is declined.
+	Confirm func(ctx context.Context, prompt string) bool
+	// Profile names the trainer whose Pokedex is loaded, saved in Profiles.
+	Profile  string
+	Profiles ProfileStore
+	// CurrentLocation is the area last explored, recorded on each catch.
+	CurrentLocation string
+	// Settings are the trainer's preferences, saved with their profile.
+	Settings ProfileSettings
 }
-type Pokedex map[string]PokemonInformation
-
-func (p Pokedex) AddPokemon(pokemon PokemonInformation) {
-	_, exists := p[pokemon.Name]
-	if !exists {
-		p[pokemon.Name] = pokemon
-	}
-}
-
-func (p Pokedex) GetPokemon(name string) (PokemonInformation, error) {
-	pokemon, exists := p[name]
-	if !exists {
-		return PokemonInformation{}, errors.New("Pokemon not found")
-	}
-	return pokemon, nil
-}
-
-type PokemonEncounter struct {
-	Pokemon struct {
-		Name string `json:"name"`
-		URL  string `json:"url"`
-	} `json:"pokemon"`
-	VersionDetails []struct {
-		EncounterDetails []struct {
-			Chance          int   `json:"chance"`
-			ConditionValues []any `json:"condition_values"`
-			MaxLevel        int   `json:"max_level"`
-			Method          struct {
-				Name string `json:"name"`
-				URL  string `json:"url"`
-			} `json:"method"`
-			MinLevel int `json:"min_level"`
-		} `json:"encounter_details"`
-		MaxChance int `json:"max_chance"`
-		Version   struct {
-			Name string `json:"name"`
-			URL  string `json:"url"`
-		} `json:"version"`
-	} `json:"version_details"`
-}
-type PokemonEncountersResponse struct {
-	EncounterMethodRates []struct {
-		EncounterMethod struct {
-			Name string `json:"name"`
-			URL  string `json:"url"`
-		} `json:"encounter_method"`
-		VersionDetails []struct {
-			Rate    int `json:"rate"`
-			Version struct {
-				Name string `json:"name"`
-				URL  string `json:"url"`
-			} `json:"version"`
-		} `json:"version_details"`
-	} `json:"encounter_method_rates"`
-	GameIndex int `json:"game_index"`
-	ID        int `json:"id"`
-	Location  struct {
-		Name string `json:"name"`
-		URL  string `json:"url"`
-	} `json:"location"`
-	Name  string `json:"name"`
-	Names []struct {
-		Language struct {
-			Name string `json:"name"`
-			URL  string `json:"url"`
-		} `json:"language"`
-		Name string `json:"name"`
-	} `json:"names"`
-	PokemonEncounters []PokemonEncounter `json:"pokemon_encounters"`
-}
+
+// The PokeAPI payloads are defined next to the client that decodes them.
+type (
+	Location                  = pokeapiclient.Location
+	GetLocationsResponse      = pokeapiclient.GetLocationsResponse
+	PokemonEncounter          = pokeapiclient.PokemonEncounter
+	PokemonEncountersResponse = pokeapiclient.PokemonEncountersResponse
+	PokemonInformation        = pokeapiclient.PokemonInformation
+)
+
 type CallbackResponse interface {
 	Response() interface{}
 	Print()
 }
 
 type InspectCommandResponse struct {
-	Pokemon PokemonInformation
+	Pokemon CaughtPokemon
+	// Details is the full PokeAPI payload, when it was asked for.
+	Details *PokemonInformation
 }
 
 func (h InspectCommandResponse) Response() interface{} {
 	return h.Pokemon
 }
 func (h InspectCommandResponse) Print() {
-	if h.Pokemon.Caught {
-		fmt.Printf("Name: %s\n", h.Pokemon.Name)
-		fmt.Printf("Height: %d\n", h.Pokemon.Height)
-		fmt.Printf("Weight: %d\n", h.Pokemon.Weight)
-		fmt.Println("Stats:")
-		for _, stat := range h.Pokemon.Stats {
-			fmt.Printf("%s: %v\n", stat.Stat.Name, stat.BaseStat)
-		}
-		fmt.Println("Types:")
-		for _, state := range h.Pokemon.Types {
-			fmt.Printf("- %s\n", state.Type.Name)
-		}
-	} else {
+	if h.Pokemon.Name == "" {
 		fmt.Println("You haven't caught this pokemon yet!")
+		return
+	}
+	fmt.Printf("Name: %s (#%d)\n", h.Pokemon.Name, h.Pokemon.InstanceID)
+	if h.Pokemon.Nickname != "" {
+		fmt.Printf("Nickname: %s\n", h.Pokemon.Nickname)
+	}
+	fmt.Printf("Level: %d\n", h.Pokemon.Level)
+	fmt.Printf("Height: %d\n", h.Pokemon.Height)
+	fmt.Printf("Weight: %d\n", h.Pokemon.Weight)
+	fmt.Println("Stats:")
+	for _, stat := range h.Pokemon.Stats {
+		fmt.Printf("%s: %v (IV %d)\n", stat.Name, stat.Base, h.Pokemon.IVs[stat.Name])
+	}
+	fmt.Println("Types:")
+	for _, name := range h.Pokemon.Types {
+		fmt.Printf("- %s\n", name)
+	}
+	fmt.Println("Abilities:")
+	for _, name := range h.Pokemon.Abilities {
+		fmt.Printf("- %s\n", name)
+	}
+	if h.Pokemon.CaughtLocation != "" {
+		fmt.Printf("Caught: %s at %s\n", h.Pokemon.CaughtAt.Local().Format("2006-01-02 15:04"), h.Pokemon.CaughtLocation)
+	} else if !h.Pokemon.CaughtAt.IsZero() {
+		fmt.Printf("Caught: %s\n", h.Pokemon.CaughtAt.Local().Format("2006-01-02 15:04"))
+	}
+	if h.Details == nil {
+		return
+	}
+	fmt.Printf("Species: %s\n", h.Details.Species.Name)
+	fmt.Printf("Base experience: %d\n", h.Details.BaseExperience)
+	if h.Details.Sprites.FrontDefault != "" {
+		fmt.Printf("Sprite: %s\n", h.Details.Sprites.FrontDefault)
+	}
+	fmt.Printf("Moves (%d):\n", len(h.Details.Moves))
+	for _, move := range h.Details.Moves {
+		fmt.Printf("- %s\n", move.Move.Name)
 	}
 }
 
 type PokedexCommandResponse struct {
 	Pokedex Pokedex
+	// Pokemon is what to list, in order; just the requested page when
+	// Page is set.
+	Pokemon []CaughtPokemon
+	Page    int
+	Pages   int
+	// Columns lists the pokemon compactly, several to a line.
+	Columns bool
 }
 
 func (h PokedexCommandResponse) Response() interface{} {
@@ -122,8 +113,85 @@ func (h PokedexCommandResponse) Response() interface{} {
 }
 func (h PokedexCommandResponse) Print() {
 	fmt.Println("Your Pokedex:")
-	for key := range h.Pokedex {
-		fmt.Printf(" - %s\n", key)
+	if len(h.Pokemon) == 0 {
+		fmt.Println("Nothing to show")
+	}
+	if h.Columns {
+		printColumns(h.Pokemon)
+	} else {
+		for _, pokemon := range h.Pokemon {
+			fmt.Printf(" - #%d [%04d] %s (level %d)\n", pokemon.InstanceID, pokemon.ID, pokemon.DisplayName(), pokemon.Level)
+		}
+	}
+	if h.Page > 0 {
+		fmt.Printf("Page %d of %d\n", h.Page, h.Pages)
+	}
+}
+
+// screenWidth is how wide printColumns assumes the terminal is.
+const screenWidth = 80
+
+// printColumns lays pokemon out down and then across as many columns as fit.
+// Like the list view, each cell shows the box number, then the dex number.
+func printColumns(pokemon []CaughtPokemon) {
+	cells := make([]string, len(pokemon))
+	width := 0
+	for i, p := range pokemon {
+		cells[i] = fmt.Sprintf("#%d [%04d] %s", p.InstanceID, p.ID, p.DisplayName())
+		width = max(width, len(cells[i])+2)
+	}
+	columns := max(1, screenWidth/max(width, 1))
+	rows := (len(cells) + columns - 1) / columns
+	for row := 0; row < rows; row++ {
+		line := ""
+		for column := 0; column < columns; column++ {
+			if i := column*rows + row; i < len(cells) {
+				line += fmt.Sprintf("%-*s", width, cells[i])
+			}
+		}
+		fmt.Println(strings.TrimRight(line, " "))
+	}
+}
+
+type PokedexProgressResponse struct {
+	Progress []GenerationProgress
+}
+
+func (h PokedexProgressResponse) Response() interface{} {
+	return h.Progress
+}
+func (h PokedexProgressResponse) Print() {
+	seen, caught, total := 0, 0, 0
+	for _, generation := range h.Progress {
+		fmt.Printf("%-16s seen %4d  caught %4d / %4d  %5.1f%%\n", generation.Generation.Name, generation.Seen, generation.Caught, generation.Generation.Total(), generation.Completion())
+		seen += generation.Seen
+		caught += generation.Caught
+		total += generation.Generation.Total()
+	}
+	if total > 0 {
+		fmt.Printf("%-16s seen %4d  caught %4d / %4d  %5.1f%%\n", "national", seen, caught, total, 100*float64(caught)/float64(total))
+	}
+}
+
+type PokedexSeenResponse struct {
+	Species []SpeciesEntry
+}
+
+func (h PokedexSeenResponse) Response() interface{} {
+	return h.Species
+}
+func (h PokedexSeenResponse) Print() {
+	if len(h.Species) == 0 {
+		fmt.Println("You have caught everything you have seen")
+		return
+	}
+	fmt.Println("Seen but not caught:")
+	for _, entry := range h.Species {
+		where := ""
+		if entry.FirstSeenLocation != "" {
+			where = " at " + entry.FirstSeenLocation
+		}
+		fmt.Printf(" - %s, first seen %s%s\n", entry.Name, entry.FirstSeenAt.Local().Format("2006-01-02 15:04"), where)
 	}
 }
 
@@ -142,6 +210,8 @@ func (h ExploreCommandResponse) Print() {
 
 type PokemonInformationResponse struct {
 	Information PokemonInformation
+	// Caught is the new box entry, when the catch succeeded.
+	Caught *CaughtPokemon
 }
 
 func (h PokemonInformationResponse) Response() interface{} {
@@ -150,7 +220,11 @@ func (h PokemonInformationResponse) Response() interface{} {
 func (h PokemonInformationResponse) Print() {
 	fmt.Printf("Throwing a Pokeball at %s\n", h.Information.Name)
 	if h.Information.Caught {
-		fmt.Printf("You caught %s!\n", h.Information.Name)
+		if h.Caught != nil {
+			fmt.Printf("You caught %s! It is #%d in your box, at level %d.\n", h.Information.Name, h.Caught.InstanceID, h.Caught.Level)
+		} else {
+			fmt.Printf("You caught %s!\n", h.Information.Name)
+		}
 		fmt.Println("You may now inspect it with the inspect command.")
 	} else {
 		fmt.Printf("Oh no! %s got away!\n", h.Information.Name)
@@ -158,6 +232,211 @@ func (h PokemonInformationResponse) Print() {
 
 }
 
+type StatsCommandResponse struct {
+	Limiter *pokeapiclient.RateLimiterStats
+}
+
+func (h StatsCommandResponse) Response() interface{} {
+	return h.Limiter
+}
+func (h StatsCommandResponse) Print() {
+	if h.Limiter == nil {
+		fmt.Println("Rate limiting is disabled")
+		return
+	}
+	fmt.Println("Rate limiter:")
+	fmt.Printf("Limit: %v requests/sec (burst %d)\n", h.Limiter.RequestsPerSecond, h.Limiter.Burst)
+	fmt.Printf("Requests: %d\n", h.Limiter.Requests)
+	fmt.Printf("Delayed: %d\n", h.Limiter.Delayed)
+	fmt.Printf("Total wait: %v\n", h.Limiter.TotalWait)
+	fmt.Printf("Longest wait: %v\n", h.Limiter.MaxWait)
+}
+
+type CacheCommandResponse struct {
+	Stats   *pokecache.Stats
+	Entries []pokecache.EntryInfo
+	Message string
+}
+
+func (h CacheCommandResponse) Response() interface{} {
+	if h.Stats != nil {
+		return *h.Stats
+	}
+	if h.Entries != nil {
+		return h.Entries
+	}
+	return h.Message
+}
+func (h CacheCommandResponse) Print() {
+	if h.Stats != nil {
+		fmt.Println("Cache:")
+		fmt.Printf("Entries: %d\n", h.Stats.Entries)
+		fmt.Printf("Bytes: %d\n", h.Stats.Bytes)
+		fmt.Printf("Hits: %d\n", h.Stats.Hits)
+		fmt.Printf("Stale hits: %d\n", h.Stats.StaleHits)
+		fmt.Printf("Misses: %d\n", h.Stats.Misses)
+		fmt.Printf("Evictions: %d\n", h.Stats.Evictions)
+		fmt.Printf("Expirations: %d\n", h.Stats.Expirations)
+		if h.Stats.DiskErrors > 0 {
+			fmt.Printf("Disk errors: %d (last: %s)\n", h.Stats.DiskErrors, h.Stats.LastDiskError)
+		}
+		return
+	}
+	if h.Entries != nil {
+		if len(h.Entries) == 0 {
+			fmt.Println("The cache is empty")
+		}
+		for _, entry := range h.Entries {
+			if entry.ExpiresIn <= 0 {
+				fmt.Printf("%s (%d bytes, age %v, stale)\n", entry.Key, entry.Size, entry.Age.Round(time.Second))
+				continue
+			}
+			fmt.Printf("%s (%d bytes, age %v, expires in %v)\n", entry.Key, entry.Size, entry.Age.Round(time.Second), entry.ExpiresIn.Round(time.Second))
+		}
+		return
+	}
+	fmt.Println(h.Message)
+}
+
+type RecordCommandResponse struct {
+	Message string
+}
+
+func (h RecordCommandResponse) Response() interface{} {
+	return h.Message
+}
+func (h RecordCommandResponse) Print() {
+	fmt.Println(h.Message)
+}
+
+type PrefetchFailure struct {
+	Resource string
+	Err      error
+}
+
+type PrefetchCommandResponse struct {
+	Fetched  int
+	Failures []PrefetchFailure
+}
+
+func (h PrefetchCommandResponse) Response() interface{} {
+	return h
+}
+func (h PrefetchCommandResponse) Print() {
+	fmt.Printf("Prefetched %d resources\n", h.Fetched)
+	if len(h.Failures) == 0 {
+		return
+	}
+	fmt.Printf("%d failed:\n", len(h.Failures))
+	for _, failure := range h.Failures {
+		fmt.Printf(" - %s: %s\n", failure.Resource, failure.Err)
+	}
+}
+
+type SaveCommandResponse struct {
+	Path  string
+	Count int
+}
+
+func (h SaveCommandResponse) Response() interface{} {
+	return h.Path
+}
+func (h SaveCommandResponse) Print() {
+	fmt.Printf("Saved %d pokemon to %s\n", h.Count, h.Path)
+}
+
+type LoadCommandResponse struct {
+	Path  string
+	Count int
+}
+
+func (h LoadCommandResponse) Response() interface{} {
+	return h.Path
+}
+func (h LoadCommandResponse) Print() {
+	fmt.Printf("Loaded %d pokemon from %s\n", h.Count, h.Path)
+}
+
+type ReleaseCommandResponse struct {
+	Released *ReleaseRecord
+	History  []ReleaseRecord
+	Message  string
+}
+
+func (h ReleaseCommandResponse) Response() interface{} {
+	if h.Released != nil {
+		return *h.Released
+	}
+	if h.History != nil {
+		return h.History
+	}
+	return h.Message
+}
+func (h ReleaseCommandResponse) Print() {
+	if h.Released != nil {
+		fmt.Printf("Bye, %s! #%d was released.\n", h.Released.displayName(), h.Released.InstanceID)
+		return
+	}
+	if h.History == nil {
+		fmt.Println(h.Message)
+		return
+	}
+	if len(h.History) == 0 {
+		fmt.Println("You haven't released any pokemon")
+	}
+	for _, record := range h.History {
+		fmt.Printf("%s: #%d %s (level %d)\n", record.ReleasedAt.Local().Format("2006-01-02 15:04"), record.InstanceID, record.displayName(), record.Level)
+	}
+}
+
+type NicknameCommandResponse struct {
+	Pokemon CaughtPokemon
+}
+
+func (h NicknameCommandResponse) Response() interface{} {
+	return h.Pokemon
+}
+func (h NicknameCommandResponse) Print() {
+	if h.Pokemon.Nickname == "" {
+		fmt.Printf("#%d %s no longer has a nickname\n", h.Pokemon.InstanceID, h.Pokemon.Name)
+		return
+	}
+	fmt.Printf("#%d is now called %s\n", h.Pokemon.InstanceID, h.Pokemon.DisplayName())
+}
+
+type ProfileCommandResponse struct {
+	Current  string
+	Profiles []ProfileSummary
+	Message  string
+}
+
+func (h ProfileCommandResponse) Response() interface{} {
+	if h.Profiles != nil {
+		return h.Profiles
+	}
+	return h.Message
+}
+func (h ProfileCommandResponse) Print() {
+	if h.Profiles == nil {
+		fmt.Println(h.Message)
+		return
+	}
+	if len(h.Profiles) == 0 {
+		fmt.Println("There are no saved profiles yet")
+	}
+	for _, profile := range h.Profiles {
+		marker := " "
+		if profile.Name == h.Current {
+			marker = "*"
+		}
+		if profile.SavedAt.IsZero() {
+			fmt.Printf("%s %s: %d caught\n", marker, profile.Name, profile.Caught)
+			continue
+		}
+		fmt.Printf("%s %s: %d caught (saved %s)\n", marker, profile.Name, profile.Caught, profile.SavedAt.Local().Format("2006-01-02 15:04"))
+	}
+}
+
 type HelpCommandResponse struct {
 	CliCommandMapType
 }
@@ -200,310 +479,19 @@ func (h MapCommandResponse) Print() {
 	}
 }
 
-type Location struct {
-	Name string
-	URL  string
-}
-type GetLocationsResponse struct {
-	Count    int
-	Next     string
-	Previous string
-	Results  []Location
-}
-
-type CallbackFunction func(*Config, Dependency, string) (CallbackResponse, error)
+type CallbackFunction func(context.Context, *Config, Dependency, string) (CallbackResponse, error)
 
 type CliCommand struct {
 	Name        string
 	Description string
 	Callback    CallbackFunction
+	// KeepCase passes the arguments through as typed instead of lowercased,
+	// for commands that take file paths.
+	KeepCase bool
 }
 
 type CliCommandMapType map[string]CliCommand
-type PokemonInformation struct {
-	Caught    bool `json:"caught"`
-	Abilities []struct {
-		Ability struct {
-			Name string `json:"name"`
-			URL  string `json:"url"`
-		} `json:"ability"`
-		IsHidden bool `json:"is_hidden"`
-		Slot     int  `json:"slot"`
-	} `json:"abilities"`
-	BaseExperience int `json:"base_experience"`
-	Cries          struct {
-		Latest string `json:"latest"`
-		Legacy string `json:"legacy"`
-	} `json:"cries"`
-	Forms []struct {
-		Name string `json:"name"`
-		URL  string `json:"url"`
-	} `json:"forms"`
-	GameIndices []struct {
-		GameIndex int `json:"game_index"`
-		Version   struct {
-			Name string `json:"name"`
-			URL  string `json:"url"`
-		} `json:"version"`
-	} `json:"game_indices"`
-	Height    int `json:"height"`
-	HeldItems []struct {
-		Item struct {
-			Name string `json:"name"`
-			URL  string `json:"url"`
-		} `json:"item"`
-		VersionDetails []struct {
-			Rarity  int `json:"rarity"`
-			Version struct {
-				Name string `json:"name"`
-				URL  string `json:"url"`
-			} `json:"version"`
-		} `json:"version_details"`
-	} `json:"held_items"`
-	ID                     int    `json:"id"`
-	IsDefault              bool   `json:"is_default"`
-	LocationAreaEncounters string `json:"location_area_encounters"`
-	Moves                  []struct {
-		Move struct {
-			Name string `json:"name"`
-			URL  string `json:"url"`
-		} `json:"move"`
-		VersionGroupDetails []struct {
-			LevelLearnedAt  int `json:"level_learned_at"`
-			MoveLearnMethod struct {
-				Name string `json:"name"`
-				URL  string `json:"url"`
-			} `json:"move_learn_method"`
-			VersionGroup struct {
-				Name string `json:"name"`
-				URL  string `json:"url"`
-			} `json:"version_group"`
-		} `json:"version_group_details"`
-	} `json:"moves"`
-	Name          string `json:"name"`
-	Order         int    `json:"order"`
-	PastAbilities []any  `json:"past_abilities"`
-	PastTypes     []struct {
-		Generation struct {
-			Name string `json:"name"`
-			URL  string `json:"url"`
-		} `json:"generation"`
-		Types []struct {
-			Slot int `json:"slot"`
-			Type struct {
-				Name string `json:"name"`
-				URL  string `json:"url"`
-			} `json:"type"`
-		} `json:"types"`
-	} `json:"past_types"`
-	Species struct {
-		Name string `json:"name"`
-		URL  string `json:"url"`
-	} `json:"species"`
-	Sprites struct {
-		BackDefault      string `json:"back_default"`
-		BackFemale       any    `json:"back_female"`
-		BackShiny        string `json:"back_shiny"`
-		BackShinyFemale  any    `json:"back_shiny_female"`
-		FrontDefault     string `json:"front_default"`
-		FrontFemale      any    `json:"front_female"`
-		FrontShiny       string `json:"front_shiny"`
-		FrontShinyFemale any    `json:"front_shiny_female"`
-		Other            struct {
-			DreamWorld struct {
-				FrontDefault string `json:"front_default"`
-				FrontFemale  any    `json:"front_female"`
-			} `json:"dream_world"`
-			Home struct {
-				FrontDefault     string `json:"front_default"`
-				FrontFemale      any    `json:"front_female"`
-				FrontShiny       string `json:"front_shiny"`
-				FrontShinyFemale any    `json:"front_shiny_female"`
-			} `json:"home"`
-			OfficialArtwork struct {
-				FrontDefault string `json:"front_default"`
-				FrontShiny   string `json:"front_shiny"`
-			} `json:"official-artwork"`
-			Showdown struct {
-				BackDefault      string `json:"back_default"`
-				BackFemale       any    `json:"back_female"`
-				BackShiny        string `json:"back_shiny"`
-				BackShinyFemale  any    `json:"back_shiny_female"`
-				FrontDefault     string `json:"front_default"`
-				FrontFemale      any    `json:"front_female"`
-				FrontShiny       string `json:"front_shiny"`
-				FrontShinyFemale any    `json:"front_shiny_female"`
-			} `json:"showdown"`
-		} `json:"other"`
-		Versions struct {
-			GenerationI struct {
-				RedBlue struct {
-					BackDefault      string `json:"back_default"`
-					BackGray         string `json:"back_gray"`
-					BackTransparent  string `json:"back_transparent"`
-					FrontDefault     string `json:"front_default"`
-					FrontGray        string `json:"front_gray"`
-					FrontTransparent string `json:"front_transparent"`
-				} `json:"red-blue"`
-				Yellow struct {
-					BackDefault      string `json:"back_default"`
-					BackGray         string `json:"back_gray"`
-					BackTransparent  string `json:"back_transparent"`
-					FrontDefault     string `json:"front_default"`
-					FrontGray        string `json:"front_gray"`
-					FrontTransparent string `json:"front_transparent"`
-				} `json:"yellow"`
-			} `json:"generation-i"`
-			GenerationIi struct {
-				Crystal struct {
-					BackDefault           string `json:"back_default"`
-					BackShiny             string `json:"back_shiny"`
-					BackShinyTransparent  string `json:"back_shiny_transparent"`
-					BackTransparent       string `json:"back_transparent"`
-					FrontDefault          string `json:"front_default"`
-					FrontShiny            string `json:"front_shiny"`
-					FrontShinyTransparent string `json:"front_shiny_transparent"`
-					FrontTransparent      string `json:"front_transparent"`
-				} `json:"crystal"`
-				Gold struct {
-					BackDefault      string `json:"back_default"`
-					BackShiny        string `json:"back_shiny"`
-					FrontDefault     string `json:"front_default"`
-					FrontShiny       string `json:"front_shiny"`
-					FrontTransparent string `json:"front_transparent"`
-				} `json:"gold"`
-				Silver struct {
-					BackDefault      string `json:"back_default"`
-					BackShiny        string `json:"back_shiny"`
-					FrontDefault     string `json:"front_default"`
-					FrontShiny       string `json:"front_shiny"`
-					FrontTransparent string `json:"front_transparent"`
-				} `json:"silver"`
-			} `json:"generation-ii"`
-			GenerationIii struct {
-				Emerald struct {
-					FrontDefault string `json:"front_default"`
-					FrontShiny   string `json:"front_shiny"`
-				} `json:"emerald"`
-				FireredLeafgreen struct {
-					BackDefault  string `json:"back_default"`
-					BackShiny    string `json:"back_shiny"`
-					FrontDefault string `json:"front_default"`
-					FrontShiny   string `json:"front_shiny"`
-				} `json:"firered-leafgreen"`
-				RubySapphire struct {
-					BackDefault  string `json:"back_default"`
-					BackShiny    string `json:"back_shiny"`
-					FrontDefault string `json:"front_default"`
-					FrontShiny   string `json:"front_shiny"`
-				} `json:"ruby-sapphire"`
-			} `json:"generation-iii"`
-			GenerationIv struct {
-				DiamondPearl struct {
-					BackDefault      string `json:"back_default"`
-					BackFemale       any    `json:"back_female"`
-					BackShiny        string `json:"back_shiny"`
-					BackShinyFemale  any    `json:"back_shiny_female"`
-					FrontDefault     string `json:"front_default"`
-					FrontFemale      any    `json:"front_female"`
-					FrontShiny       string `json:"front_shiny"`
-					FrontShinyFemale any    `json:"front_shiny_female"`
-				} `json:"diamond-pearl"`
-				HeartgoldSoulsilver struct {
-					BackDefault      string `json:"back_default"`
-					BackFemale       any    `json:"back_female"`
-					BackShiny        string `json:"back_shiny"`
-					BackShinyFemale  any    `json:"back_shiny_female"`
-					FrontDefault     string `json:"front_default"`
-					FrontFemale      any    `json:"front_female"`
-					FrontShiny       string `json:"front_shiny"`
-					FrontShinyFemale any    `json:"front_shiny_female"`
-				} `json:"heartgold-soulsilver"`
-				Platinum struct {
-					BackDefault      string `json:"back_default"`
-					BackFemale       any    `json:"back_female"`
-					BackShiny        string `json:"back_shiny"`
-					BackShinyFemale  any    `json:"back_shiny_female"`
-					FrontDefault     string `json:"front_default"`
-					FrontFemale      any    `json:"front_female"`
-					FrontShiny       string `json:"front_shiny"`
-					FrontShinyFemale any    `json:"front_shiny_female"`
-				} `json:"platinum"`
-			} `json:"generation-iv"`
-			GenerationV struct {
-				BlackWhite struct {
-					Animated struct {
-						BackDefault      string `json:"back_default"`
-						BackFemale       any    `json:"back_female"`
-						BackShiny        string `json:"back_shiny"`
-						BackShinyFemale  any    `json:"back_shiny_female"`
-						FrontDefault     string `json:"front_default"`
-						FrontFemale      any    `json:"front_female"`
-						FrontShiny       string `json:"front_shiny"`
-						FrontShinyFemale any    `json:"front_shiny_female"`
-					} `json:"animated"`
-					BackDefault      string `json:"back_default"`
-					BackFemale       any    `json:"back_female"`
-					BackShiny        string `json:"back_shiny"`
-					BackShinyFemale  any    `json:"back_shiny_female"`
-					FrontDefault     string `json:"front_default"`
-					FrontFemale      any    `json:"front_female"`
-					FrontShiny       string `json:"front_shiny"`
-					FrontShinyFemale any    `json:"front_shiny_female"`
-				} `json:"black-white"`
-			} `json:"generation-v"`
-			GenerationVi struct {
-				OmegarubyAlphasapphire struct {
-					FrontDefault     string `json:"front_default"`
-					FrontFemale      any    `json:"front_female"`
-					FrontShiny       string `json:"front_shiny"`
-					FrontShinyFemale any    `json:"front_shiny_female"`
-				} `json:"omegaruby-alphasapphire"`
-				XY struct {
-					FrontDefault     string `json:"front_default"`
-					FrontFemale      any    `json:"front_female"`
-					FrontShiny       string `json:"front_shiny"`
-					FrontShinyFemale any    `json:"front_shiny_female"`
-				} `json:"x-y"`
-			} `json:"generation-vi"`
-			GenerationVii struct {
-				Icons struct {
-					FrontDefault string `json:"front_default"`
-					FrontFemale  any    `json:"front_female"`
-				} `json:"icons"`
-				UltraSunUltraMoon struct {
-					FrontDefault     string `json:"front_default"`
-					FrontFemale      any    `json:"front_female"`
-					FrontShiny       string `json:"front_shiny"`
-					FrontShinyFemale any    `json:"front_shiny_female"`
-				} `json:"ultra-sun-ultra-moon"`
-			} `json:"generation-vii"`
-			GenerationViii struct {
-				Icons struct {
-					FrontDefault string `json:"front_default"`
-					FrontFemale  any    `json:"front_female"`
-				} `json:"icons"`
-			} `json:"generation-viii"`
-		} `json:"versions"`
-	} `json:"sprites"`
-	Stats []struct {
-		BaseStat int `json:"base_stat"`
-		Effort   int `json:"effort"`
-		Stat     struct {
-			Name string `json:"name"`
-			URL  string `json:"url"`
-		} `json:"stat"`
-	} `json:"stats"`
-	Types []struct {
-		Slot int `json:"slot"`
-		Type struct {
-			Name string `json:"name"`
-			URL  string `json:"url"`
-		} `json:"type"`
-	} `json:"types"`
-	Weight int `json:"weight"`
-}
-
 type Dependency interface {
-	RandInt(baseExperience int) int
+	// RandInt returns a random number r with 0 <= r < n, like rand.Intn.
+	RandInt(n int) int
 }
@@ -2,58 +2,196 @@ package utils
 
 import (
 	"bufio"
+	"context"
 	"fmt"
+	"io"
 	"math/rand"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/mdwiltfong/PokeDex/internal/pokeapiclient"
+	"github.com/mdwiltfong/PokeDex/internal/pokecache"
 	"github.com/mdwiltfong/PokeDex/internal/types"
 )
 
 type StdDependency struct{}
 
-func (s StdDependency) RandInt(baseExperience int) int {
-	return rand.Intn(baseExperience)
+func (s StdDependency) RandInt(n int) int {
+	return rand.Intn(n)
 }
 
-func StartRepl() {
-	client := pokeapiclient.NewClient(50000, 5*time.Second)
+// ReplOptions carries the startup settings main hands to StartRepl.
+type ReplOptions struct {
+	BaseURL           string
+	RequestsPerSecond float64
+	Burst             int
+	// CacheTTL is how often expired responses are swept from the cache.
+	CacheTTL time.Duration
+	// LocationTTL and PokemonTTL override how long those responses are
+	// cached; zero keeps the client's defaults.
+	LocationTTL time.Duration
+	PokemonTTL  time.Duration
+	// StaleFor is how long expired responses may still be served while
+	// they are refreshed in the background.
+	StaleFor time.Duration
+	// CacheMaxEntries and CacheMaxBytes bound the cache; zero means no limit.
+	CacheMaxEntries int
+	CacheMaxBytes   int
+	// CacheBackend is "memory" (the default), "disk" to keep API responses
+	// in CacheDir between sessions, or "none" to cache nothing.
+	CacheBackend string
+	CacheDir     string
+	// OfflineDir answers every request from a snapshot instead of the
+	// network; RecordDir records a snapshot while the session runs.
+	OfflineDir string
+	RecordDir  string
+	// ProfilesDir holds one save file per trainer profile; Profile is
+	// loaded on startup and saved on exit. An empty ProfilesDir disables
+	// both.
+	ProfilesDir string
+	Profile     string
+	// LegacySavePath is where saves from before profiles were kept; one
+	// found there is imported into the default profile if that is empty.
+	LegacySavePath string
+}
+
+func StartRepl(opts ReplOptions) {
+	clientOptions := []pokeapiclient.Option{
+		pokeapiclient.WithBaseURL(opts.BaseURL),
+		pokeapiclient.WithRateLimit(opts.RequestsPerSecond, opts.Burst),
+		pokeapiclient.WithCacheTTLs(pokeapiclient.CacheTTLs{
+			Locations:     opts.LocationTTL,
+			LocationAreas: opts.LocationTTL,
+			Pokemon:       opts.PokemonTTL,
+		}),
+		pokeapiclient.WithStaleWhileRevalidate(opts.StaleFor),
+		pokeapiclient.WithOfflineSnapshot(opts.OfflineDir),
+		pokeapiclient.WithRecorder(opts.RecordDir),
+	}
+	if opts.CacheTTL <= 0 {
+		opts.CacheTTL = 5 * time.Second
+	}
+	cacheOptions := []pokecache.Option{
+		pokecache.WithMaxEntries(opts.CacheMaxEntries),
+		pokecache.WithMaxBytes(opts.CacheMaxBytes),
+	}
+	var cache pokeapiclient.Cache
+	switch opts.CacheBackend {
+	case "", "memory":
+	case "none":
+		cache = pokecache.NoopCache{}
+	case "disk":
+		diskCache, err := pokecache.NewPersistentCache(opts.CacheDir, opts.CacheTTL, cacheOptions...)
+		if err != nil {
+			fmt.Printf("Could not open the disk cache, falling back to memory: %s\n", err)
+		} else {
+			cache = diskCache
+		}
+	default:
+		fmt.Printf("Unknown cache backend %q, falling back to memory\n", opts.CacheBackend)
+	}
+	if cache == nil {
+		cache = pokecache.NewCache(opts.CacheTTL, cacheOptions...)
+	}
+	clientOptions = append(clientOptions, pokeapiclient.WithCache(cache))
+	client := pokeapiclient.NewClient(10*time.Second, opts.CacheTTL, clientOptions...)
+	defer client.Close()
 	cfg := &types.Config{
 		PREV_URL: nil,
 		NEXT_URL: nil,
 		Client:   client,
 		Pokedex:  types.Pokedex{},
+		Profiles: types.ProfileStore{Dir: opts.ProfilesDir},
+	}
+	if opts.Profile == "" {
+		opts.Profile = types.DefaultProfile
+	}
+	if cfg.Profiles.Dir != "" && opts.LegacySavePath != "" {
+		imported, err := cfg.Profiles.ImportLegacySave(opts.LegacySavePath)
+		if err != nil {
+			fmt.Printf("Could not import your Pokedex from %s: %s\n", opts.LegacySavePath, err)
+		} else if imported {
+			fmt.Printf("Imported your Pokedex from %s into the %s profile\n", opts.LegacySavePath, types.DefaultProfile)
+		}
+	}
+	if cfg.Profiles.Dir != "" {
+		if err := cfg.SwitchProfile(opts.Profile); err != nil {
+			// Don't let autosave overwrite a file we could not read.
+			fmt.Printf("Could not load profile %s, autosave is off for this session: %s\n", opts.Profile, err)
+		}
+	}
+	defer autosave(cfg)
+	interrupts := handleInterrupts(cfg)
+	defer interrupts.stop()
+	lines := readLines(os.Stdin)
+	cfg.Confirm = func(ctx context.Context, prompt string) bool {
+		fmt.Printf("%s [y/N] ", prompt)
+		select {
+		case line, ok := <-lines:
+			answer := strings.ToLower(strings.TrimSpace(line))
+			return ok && (answer == "y" || answer == "yes")
+		case <-ctx.Done():
+			fmt.Println()
+			return false
+		}
 	}
-	scanner := bufio.NewScanner(os.Stdin)
 	cliMap := CliCommandMap()
 
 	fmt.Print("PokeDex > ")
 
-	for scanner.Scan() {
-		input := scanner.Text()
+	for input := range lines {
 
 		sanitizedInput := SanitizeInput(input)
 		command, exists := cliMap[sanitizedInput[0]]
 		if exists {
 			var response types.CallbackResponse
 			var err error
-			if len(sanitizedInput) == 2 {
-				response, err = command.Callback(cfg, StdDependency{}, sanitizedInput[1])
-			} else {
-				response, err = command.Callback(cfg, StdDependency{}, "")
+			// While a command runs, Ctrl-C cancels it instead of ending the REPL.
+			ctx, done := interrupts.command()
+			args := strings.Join(sanitizedInput[1:], " ")
+			if command.KeepCase {
+				args = strings.Join(strings.Split(strings.TrimSpace(input), " ")[1:], " ")
 			}
+			response, err = command.Callback(ctx, cfg, StdDependency{}, args)
+			done()
 			if err != nil {
 				fmt.Println(err.Error())
+			} else {
+				response.Print()
 			}
-			response.Print()
 		} else {
 			fmt.Println("Hmm, this command doesn't exist. Try again")
 		}
-		if sanitizedInput[0] == "exit" {
+		if sanitizedInput[0] == "exit" || interrupts.quitting() {
 			return
 		}
 		fmt.Print("PokeDex > ")
 
 	}
 }
+
+// readLines reads r line by line in the background, so a prompt can stop
+// waiting for an answer when its command is interrupted. The channel is
+// closed at the end of the input.
+func readLines(r io.Reader) <-chan string {
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+	return lines
+}
+
+// autosave writes the Pokedex to cfg.SavePath when the REPL ends.
+func autosave(cfg *types.Config) {
+	if cfg.SavePath == "" {
+		return
+	}
+	if err := types.WriteSaveFile(cfg.SavePath, cfg.SaveFile()); err != nil {
+		fmt.Printf("Could not save your Pokedex: %s\n", err)
+	}
+}
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mdwiltfong/PokeDex/internal/types"
+)
+
+// Nickname names a pokemon in the box, keeping the nickname's case as typed.
+// Leaving the nickname out removes it.
+func Nickname(ctx context.Context, config *types.Config, dependency types.Dependency, commandInput string) (types.CallbackResponse, error) {
+	args := strings.Fields(commandInput)
+	if len(args) == 0 || len(args) > 2 {
+		return types.NicknameCommandResponse{}, errors.New("Please enter a pokemon and its new nickname: nickname <pokemon or #id> [nickname]")
+	}
+	target := strings.ToLower(args[0])
+	matches := config.Pokedex.Matching(target)
+	switch len(matches) {
+	case 0:
+		return types.NicknameCommandResponse{}, fmt.Errorf("You don't have %s in your box", target)
+	case 1:
+	default:
+		return types.NicknameCommandResponse{}, fmt.Errorf("You have %d %s, nickname one by number", len(matches), target)
+	}
+	nickname := ""
+	if len(args) == 2 {
+		nickname = args[1]
+	}
+	if err := config.Pokedex.SetNickname(matches[0].InstanceID, nickname); err != nil {
+		return types.NicknameCommandResponse{}, err
+	}
+	pokemon, _ := config.Pokedex.GetPokemon(fmt.Sprintf("#%d", matches[0].InstanceID))
+	return types.NicknameCommandResponse{Pokemon: pokemon}, nil
+}
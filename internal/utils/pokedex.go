@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mdwiltfong/PokeDex/internal/types"
+)
+
+// pokedexPageSize is how many pokemon pokedex page:<n> shows at a time.
+const pokedexPageSize = 20
+
+// Pokedex lists the box, or with progress or seen, the dex. The box is in
+// national dex order unless sort:<order> or the profile's settings say
+// otherwise; prefix the order with - to reverse it. columns and list pick
+// the layout, again defaulting to the profile's. Any other arguments are
+// filters, see types.ParseFilter, and only pokemon matching all of them are
+// listed.
+func Pokedex(ctx context.Context, config *types.Config, dependency types.Dependency, commandInput string) (types.CallbackResponse, error) {
+	switch commandInput {
+	case "progress":
+		return types.PokedexProgressResponse{Progress: config.Pokedex.Progress()}, nil
+	case "seen":
+		return types.PokedexSeenResponse{Species: seenNotCaught(config.Pokedex)}, nil
+	}
+	response := types.PokedexCommandResponse{Pokedex: config.Pokedex, Columns: config.Settings.PokedexColumns}
+	order := config.Settings.PokedexSort
+	var filters []types.PokedexFilter
+	for _, arg := range strings.Fields(commandInput) {
+		key, value, _ := strings.Cut(arg, ":")
+		switch key {
+		case "sort":
+			order = value
+		case "page":
+			page, err := strconv.Atoi(value)
+			if err != nil || page < 1 {
+				return types.PokedexCommandResponse{}, fmt.Errorf("%q is not a page number", value)
+			}
+			response.Page = page
+		case "columns":
+			response.Columns = true
+		case "list":
+			response.Columns = false
+		default:
+			filter, err := types.ParseFilter(arg)
+			if err != nil {
+				return types.PokedexCommandResponse{}, err
+			}
+			filters = append(filters, filter)
+		}
+	}
+	by, reverse, err := parseSort(order)
+	if err != nil {
+		return types.PokedexCommandResponse{}, err
+	}
+	pokemon, err := config.Pokedex.Sorted(by, reverse)
+	if err != nil {
+		return types.PokedexCommandResponse{}, err
+	}
+	pokemon = matchingAll(pokemon, filters)
+	if response.Page > 0 {
+		response.Pages = max(1, (len(pokemon)+pokedexPageSize-1)/pokedexPageSize)
+		if response.Page > response.Pages {
+			return types.PokedexCommandResponse{}, fmt.Errorf("There are only %d pages", response.Pages)
+		}
+		start := (response.Page - 1) * pokedexPageSize
+		pokemon = pokemon[start:min(start+pokedexPageSize, len(pokemon))]
+	}
+	response.Pokemon = pokemon
+	return response, nil
+}
+
+// parseSort reads an order as written after sort:, such as "-exp". An empty
+// order is dex order.
+func parseSort(order string) (types.PokedexSort, bool, error) {
+	if order == "" {
+		return types.SortByDex, false, nil
+	}
+	by := types.PokedexSort(strings.TrimPrefix(order, "-"))
+	for _, known := range types.PokedexSorts {
+		if by == known {
+			return by, strings.HasPrefix(order, "-"), nil
+		}
+	}
+	sorts := make([]string, len(types.PokedexSorts))
+	for i, known := range types.PokedexSorts {
+		sorts[i] = string(known)
+	}
+	return "", false, fmt.Errorf("Unknown sort order %q: use one of %s", by, strings.Join(sorts, ", "))
+}
+
+func matchingAll(pokemon []types.CaughtPokemon, filters []types.PokedexFilter) []types.CaughtPokemon {
+	matches := []types.CaughtPokemon{}
+next:
+	for _, p := range pokemon {
+		for _, filter := range filters {
+			if !filter(p) {
+				continue next
+			}
+		}
+		matches = append(matches, p)
+	}
+	return matches
+}
+
+// seenNotCaught lists the species seen but never caught, in dex order.
+func seenNotCaught(pokedex types.Pokedex) []types.SpeciesEntry {
+	seen := []types.SpeciesEntry{}
+	for _, entry := range pokedex.Species {
+		if !entry.Caught() {
+			seen = append(seen, entry)
+		}
+	}
+	sort.Slice(seen, func(i, j int) bool {
+		if seen[i].ID != seen[j].ID {
+			return seen[i].ID < seen[j].ID
+		}
+		return seen[i].Name < seen[j].Name
+	})
+	return seen
+}
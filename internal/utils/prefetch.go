@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mdwiltfong/PokeDex/internal/pokeapiclient"
+	"github.com/mdwiltfong/PokeDex/internal/types"
+)
+
+// prefetchWorkers bounds how many requests prefetch keeps in flight. The
+// client's rate limiter still applies on top of it.
+const prefetchWorkers = 4
+
+// Prefetch warms the cache for a region, generation, location or list of
+// pokemon by walking down to every location area and pokemon involved.
+func Prefetch(ctx context.Context, config *types.Config, dependency types.Dependency, commandInput string) (types.CallbackResponse, error) {
+	kind, rest, _ := strings.Cut(commandInput, " ")
+	names := strings.Fields(rest)
+	if len(names) == 0 {
+		return types.PrefetchCommandResponse{}, errors.New("Usage: prefetch region|generation|location|pokemon <name...>")
+	}
+	p := &prefetcher{ctx: ctx, config: config}
+
+	var pokemon []string
+	switch kind {
+	case "region":
+		locations := p.expand("region", names, func(ctx context.Context, name string) ([]string, error) {
+			region, err := config.Client.GetRegion(ctx, name)
+			return resourceNames(region.Locations), err
+		})
+		pokemon = p.locations(locations)
+	case "generation":
+		species := p.expand("generation", names, func(ctx context.Context, name string) ([]string, error) {
+			generation, err := config.Client.GetGeneration(ctx, name)
+			return resourceNames(generation.PokemonSpecies), err
+		})
+		// Generations list species, and not every species has a pokemon
+		// of the same name, so go through each species' default variety.
+		pokemon = p.expand("pokemon-species", species, func(ctx context.Context, name string) ([]string, error) {
+			species, err := config.Client.GetPokemonSpecies(ctx, name)
+			if err != nil {
+				return nil, err
+			}
+			variety, ok := species.DefaultVariety()
+			if !ok {
+				return nil, fmt.Errorf("species %s has no default pokemon", name)
+			}
+			return []string{variety}, nil
+		})
+	case "location":
+		pokemon = p.locations(names)
+	case "pokemon":
+		pokemon = names
+	default:
+		return types.PrefetchCommandResponse{}, fmt.Errorf("Cannot prefetch %q, try region, generation, location or pokemon", kind)
+	}
+	p.expand("pokemon", pokemon, func(ctx context.Context, name string) ([]string, error) {
+		_, err := config.Client.GetPokemon(ctx, name)
+		return nil, err
+	})
+
+	if err := ctx.Err(); err != nil {
+		return types.PrefetchCommandResponse{}, fmt.Errorf("Prefetch interrupted after %d resources: %w", p.fetched, err)
+	}
+	sort.Slice(p.failures, func(i, j int) bool {
+		return p.failures[i].Resource < p.failures[j].Resource
+	})
+	return types.PrefetchCommandResponse{Fetched: p.fetched, Failures: p.failures}, nil
+}
+
+type prefetcher struct {
+	ctx    context.Context
+	config *types.Config
+
+	mu       sync.Mutex
+	fetched  int
+	failures []types.PrefetchFailure
+}
+
+// locations expands locations into their areas and the areas into the
+// pokemon that can be encountered there.
+func (p *prefetcher) locations(names []string) []string {
+	areas := p.expand("location", names, func(ctx context.Context, name string) ([]string, error) {
+		location, err := p.config.Client.GetLocation(ctx, name)
+		return resourceNames(location.Areas), err
+	})
+	return p.expand("location-area", areas, func(ctx context.Context, name string) ([]string, error) {
+		area, err := p.config.Client.GetLocationArea(ctx, name)
+		pokemon := make([]string, 0, len(area.PokemonEncounters))
+		for _, encounter := range area.PokemonEncounters {
+			pokemon = append(pokemon, encounter.Pokemon.Name)
+		}
+		return pokemon, err
+	})
+}
+
+// expand runs fetch for every name with at most prefetchWorkers in flight,
+// and returns the de-duplicated, sorted names the fetches led to.
+func (p *prefetcher) expand(resource string, names []string, fetch func(context.Context, string) ([]string, error)) []string {
+	if len(names) == 0 || p.ctx.Err() != nil {
+		return nil
+	}
+	fmt.Printf("Prefetching %d %s...\n", len(names), resource)
+	found := map[string]bool{}
+	queue := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < prefetchWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range queue {
+				next, err := fetch(p.ctx, name)
+				p.mu.Lock()
+				if err != nil {
+					p.failures = append(p.failures, types.PrefetchFailure{Resource: resource + "/" + name, Err: err})
+				} else {
+					p.fetched++
+				}
+				for _, n := range next {
+					found[n] = true
+				}
+				p.mu.Unlock()
+			}
+		}()
+	}
+	for _, name := range names {
+		if p.ctx.Err() != nil {
+			break
+		}
+		queue <- name
+	}
+	close(queue)
+	wg.Wait()
+
+	next := make([]string, 0, len(found))
+	for name := range found {
+		next = append(next, name)
+	}
+	sort.Strings(next)
+	return next
+}
+
+func resourceNames(resources []pokeapiclient.NamedAPIResource) []string {
+	names := make([]string, 0, len(resources))
+	for _, resource := range resources {
+		names = append(names, resource.Name)
+	}
+	return names
+}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mdwiltfong/PokeDex/internal/types"
+)
+
+// Profile lists the trainer profiles, switches to another one, saving the
+// current profile first, or shows and changes the current profile's
+// settings.
+func Profile(ctx context.Context, config *types.Config, dependency types.Dependency, commandInput string) (types.CallbackResponse, error) {
+	args := strings.Fields(commandInput)
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+	switch args[0] {
+	case "list":
+		if config.Profiles.Dir == "" {
+			return types.ProfileCommandResponse{}, errors.New("Profiles are disabled for this session")
+		}
+		profiles, err := config.Profiles.List()
+		if err != nil {
+			return types.ProfileCommandResponse{}, fmt.Errorf("Could not list profiles: %w", err)
+		}
+		// The current profile may not be saved yet, and its file lags
+		// behind what has been caught this session.
+		current := false
+		for i := range profiles {
+			if profiles[i].Name == config.Profile {
+				profiles[i].Caught = config.Pokedex.Len()
+				current = true
+			}
+		}
+		if !current && config.Profile != "" {
+			profiles = append(profiles, types.ProfileSummary{Name: config.Profile, Caught: config.Pokedex.Len()})
+		}
+		return types.ProfileCommandResponse{Current: config.Profile, Profiles: profiles}, nil
+	case "switch":
+		if len(args) != 2 {
+			return types.ProfileCommandResponse{}, errors.New("Please enter a profile to switch to: profile switch <name>")
+		}
+		if args[1] == config.Profile {
+			return types.ProfileCommandResponse{Message: fmt.Sprintf("Already playing as %s", args[1])}, nil
+		}
+		if err := config.SwitchProfile(args[1]); err != nil {
+			return types.ProfileCommandResponse{}, fmt.Errorf("Could not switch profile: %w", err)
+		}
+		return types.ProfileCommandResponse{Message: fmt.Sprintf("Now playing as %s (%d caught)", config.Profile, config.Pokedex.Len())}, nil
+	case "settings":
+		settings := config.Settings
+		order, layout := "dex", "list"
+		if settings.PokedexSort != "" {
+			order = settings.PokedexSort
+		}
+		if settings.PokedexColumns {
+			layout = "columns"
+		}
+		return types.ProfileCommandResponse{Message: fmt.Sprintf("Pokedex sort: %s\nPokedex layout: %s", order, layout)}, nil
+	case "set":
+		if len(args) != 3 {
+			return types.ProfileCommandResponse{}, errors.New("Please enter a setting and its value: profile set sort <order> or profile set layout list|columns")
+		}
+		return setProfileSetting(config, args[1], args[2])
+	default:
+		return types.ProfileCommandResponse{}, fmt.Errorf("Unknown profile command %q: use list, switch <name>, settings or set <setting> <value>", args[0])
+	}
+}
+
+// setProfileSetting changes one of the current profile's settings. It is
+// saved along with the Pokedex.
+func setProfileSetting(config *types.Config, setting, value string) (types.CallbackResponse, error) {
+	switch setting {
+	case "sort":
+		if _, _, err := parseSort(value); err != nil {
+			return types.ProfileCommandResponse{}, err
+		}
+		config.Settings.PokedexSort = value
+		return types.ProfileCommandResponse{Message: fmt.Sprintf("pokedex now sorts by %s", value)}, nil
+	case "layout":
+		switch value {
+		case "list":
+			config.Settings.PokedexColumns = false
+		case "columns":
+			config.Settings.PokedexColumns = true
+		default:
+			return types.ProfileCommandResponse{}, fmt.Errorf("Unknown layout %q: use list or columns", value)
+		}
+		return types.ProfileCommandResponse{Message: fmt.Sprintf("pokedex now uses the %s layout", value)}, nil
+	default:
+		return types.ProfileCommandResponse{}, fmt.Errorf("Unknown setting %q: use sort or layout", setting)
+	}
+}
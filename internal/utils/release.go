@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mdwiltfong/PokeDex/internal/types"
+)
+
+// Release lets a pokemon go from the box after asking for confirmation, or
+// shows what has been released before.
+func Release(ctx context.Context, config *types.Config, dependency types.Dependency, commandInput string) (types.CallbackResponse, error) {
+	if commandInput == "" {
+		return types.ReleaseCommandResponse{}, errors.New("Please enter a pokemon you'd like to release: release <pokemon or #id>")
+	}
+	if commandInput == "history" {
+		history := config.Pokedex.Releases
+		if history == nil {
+			history = []types.ReleaseRecord{}
+		}
+		return types.ReleaseCommandResponse{History: history}, nil
+	}
+	matches := config.Pokedex.Matching(commandInput)
+	switch len(matches) {
+	case 0:
+		return types.ReleaseCommandResponse{}, fmt.Errorf("You don't have %s in your box", commandInput)
+	case 1:
+	default:
+		ids := make([]string, len(matches))
+		for i, pokemon := range matches {
+			ids[i] = fmt.Sprintf("#%d", pokemon.InstanceID)
+		}
+		return types.ReleaseCommandResponse{}, fmt.Errorf("You have %d %s (%s), release one by number", len(matches), commandInput, strings.Join(ids, ", "))
+	}
+	pokemon := matches[0]
+	prompt := fmt.Sprintf("Release %s #%d (level %d)?", pokemon.Name, pokemon.InstanceID, pokemon.Level)
+	// An interrupt while asking declines, even if the answer was yes.
+	if config.Confirm == nil || !config.Confirm(ctx, prompt) || ctx.Err() != nil {
+		return types.ReleaseCommandResponse{Message: fmt.Sprintf("%s stays in your box", pokemon.Name)}, nil
+	}
+	record, err := config.Pokedex.Release(pokemon.InstanceID, time.Now().UTC())
+	if err != nil {
+		return types.ReleaseCommandResponse{}, err
+	}
+	return types.ReleaseCommandResponse{Released: &record}, nil
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/mdwiltfong/PokeDex/internal/types"
+)
+
+// interrupts handles Ctrl-C and SIGTERM for the lifetime of the REPL. While
+// a command runs, the signal cancels it; at the prompt, the Pokedex is saved
+// and the process exits, since the loop is stuck reading stdin. SIGTERM also
+// ends the REPL once the running command has been cancelled.
+type interrupts struct {
+	cfg     *types.Config
+	signals chan os.Signal
+
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	quit    bool
+	stopped bool
+}
+
+func handleInterrupts(cfg *types.Config) *interrupts {
+	i := &interrupts{cfg: cfg, signals: make(chan os.Signal, 1)}
+	signal.Notify(i.signals, os.Interrupt, syscall.SIGTERM)
+	go i.loop()
+	return i
+}
+
+func (i *interrupts) loop() {
+	for sig := range i.signals {
+		i.mu.Lock()
+		if i.stopped {
+			i.mu.Unlock()
+			return
+		}
+		if i.cancel != nil {
+			i.cancel()
+			i.quit = i.quit || sig == syscall.SIGTERM
+			i.mu.Unlock()
+			continue
+		}
+		// Holding mu keeps the REPL from starting a command while saving.
+		fmt.Println()
+		autosave(i.cfg)
+		fmt.Println("Okay! See you next time!")
+		code := 130
+		if sig == syscall.SIGTERM {
+			code = 143
+		}
+		os.Exit(code)
+	}
+}
+
+// command returns the context a command runs with, cancelled by the next
+// interrupt, and a func to call once the command is done.
+func (i *interrupts) command() (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	i.mu.Lock()
+	i.cancel = cancel
+	i.mu.Unlock()
+	return ctx, func() {
+		i.mu.Lock()
+		i.cancel = nil
+		i.mu.Unlock()
+		cancel()
+	}
+}
+
+// quitting reports whether a SIGTERM arrived while a command was running.
+func (i *interrupts) quitting() bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.quit
+}
+
+// stop restores the default signal handling. It is called after autosave
+// has been deferred, so a signal while the REPL shuts down is harmless.
+func (i *interrupts) stop() {
+	signal.Stop(i.signals)
+	i.mu.Lock()
+	i.stopped = true
+	i.mu.Unlock()
+}
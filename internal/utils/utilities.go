@@ -1,13 +1,14 @@
 package utils
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
-	"io"
-	"log"
+	"os"
 	"strings"
+	"time"
 
+	"github.com/mdwiltfong/PokeDex/internal/pokeapiclient"
 	"github.com/mdwiltfong/PokeDex/internal/types"
 )
 
@@ -52,18 +53,67 @@ func CliCommandMap() types.CliCommandMapType {
 		},
 		"inspect": {
 			Name:        "inspect",
-			Description: "Inspect a pokemon in the pokedex",
+			Description: "Inspect a pokemon in your box: inspect <pokemon, #id or nickname> [full]",
 			Callback:    Inspect,
 		},
 		"pokedex": {
 			Name:        "pokedex",
-			Description: "View all the pokemon in the pokedex",
+			Description: "View the pokemon in your box: pokedex [filters...] [sort:dex|name|caught|exp|type] [page:<n>] [columns|list], or your dex: pokedex progress|seen",
 			Callback:    Pokedex,
 		},
+		"stats": {
+			Name:        "stats",
+			Description: "Show how long API calls have waited on the rate limiter",
+			Callback:    Stats,
+		},
+		"cache": {
+			Name:        "cache",
+			Description: "Inspect the response cache: cache stats | list | clear | evict <key>",
+			Callback:    Cache,
+		},
+		"record": {
+			Name:        "record",
+			Description: "Record API responses into a snapshot for offline use: record <dir> | stop",
+			Callback:    Record,
+			KeepCase:    true,
+		},
+		"prefetch": {
+			Name:        "prefetch",
+			Description: "Warm the cache: prefetch region|generation|location|pokemon <name...>",
+			Callback:    Prefetch,
+		},
+		"save": {
+			Name:        "save",
+			Description: "Save the Pokedex: save [path]",
+			Callback:    Save,
+			KeepCase:    true,
+		},
+		"load": {
+			Name:        "load",
+			Description: "Load a saved Pokedex, replacing the current one: load [path]",
+			Callback:    Load,
+			KeepCase:    true,
+		},
+		"release": {
+			Name:        "release",
+			Description: "Release a pokemon from your box: release <pokemon or #id>, or release history",
+			Callback:    Release,
+		},
+		"nickname": {
+			Name:        "nickname",
+			Description: "Give a pokemon in your box a nickname: nickname <pokemon or #id> [nickname]",
+			Callback:    Nickname,
+			KeepCase:    true,
+		},
+		"profile": {
+			Name:        "profile",
+			Description: "Manage trainer profiles: profile [list|switch <name>|settings|set sort <order>|set layout list|columns]",
+			Callback:    Profile,
+		},
 	}
 }
 
-func HelpCommand(config *types.Config, dependency types.Dependency, commandInput string) (types.CallbackResponse, error) {
+func HelpCommand(ctx context.Context, config *types.Config, dependency types.Dependency, commandInput string) (types.CallbackResponse, error) {
 	fmt.Println("Welcome to the Pokedex!")
 	fmt.Println("Usage:")
 	fmt.Println("")
@@ -74,192 +124,255 @@ func HelpCommand(config *types.Config, dependency types.Dependency, commandInput
 	return types.HelpCommandResponse{CliCommandMapType: CliCommandMap()}, nil
 }
 
-func ExitCommand(config *types.Config, dep types.Dependency, commandInput string) (types.CallbackResponse, error) {
+func ExitCommand(ctx context.Context, config *types.Config, dep types.Dependency, commandInput string) (types.CallbackResponse, error) {
 	return types.ExitCommandResponse{Message: "Okay! See you next time!"}, nil
 }
 
-func Map(config *types.Config, dep types.Dependency, commandInput string) (types.CallbackResponse, error) {
-	url := "https://pokeapi.co/api/v2/location/"
+func Map(ctx context.Context, config *types.Config, dep types.Dependency, commandInput string) (types.CallbackResponse, error) {
+	url := config.Client.Endpoint("location/")
 	if config.NEXT_URL != nil {
 		url = *config.NEXT_URL
 	}
-	response, err := config.Client.HttpClient.Get(url)
-
+	locations, err := config.Client.ListLocations(ctx, url)
 	if err != nil {
-		errors.New("There was an issue with the API request")
-	}
-	body, _ := io.ReadAll(response.Body)
-	if response.StatusCode > 299 {
-		log.Fatalf("Response failed with status code: %d and\nbody: %s\n", response.StatusCode, body)
+		return types.MapCommandResponse{}, err
 	}
-	responseBytes := []byte(body)
-	var locations types.GetLocationsResponse
-	marshalingError := Unmarshall(responseBytes, &locations)
 
 	config.NEXT_URL = &locations.Next
 	config.PREV_URL = &url
-	config.Client.Cache.Add(url, responseBytes)
-	if marshalingError != nil {
-		log.Fatalf("Failed to unmarshal response: %s\n", marshalingError)
-	}
 	return types.MapCommandResponse{Locations: locations.Results}, nil
 }
 
-func Mapb(config *types.Config, dependency types.Dependency, commandInput string) (types.CallbackResponse, error) {
+func Mapb(ctx context.Context, config *types.Config, dependency types.Dependency, commandInput string) (types.CallbackResponse, error) {
 
 	if config.PREV_URL == nil || *config.PREV_URL == "" {
 		fmt.Println("There are no previous pages")
 		return types.MapCommandResponse{}, errors.New("there are no previous pages")
 	}
 
-	url := *config.PREV_URL
-	var locations types.GetLocationsResponse
-	cachedBytes, exists := config.Client.Cache.Get(url)
-	if !exists {
-		fmt.Println("No cached data!!!!")
-
-		response, err := config.Client.HttpClient.Get(url)
-		if err != nil {
-			return types.MapCommandResponse{}, errors.New("there was an issue with the API request")
-		}
-		if response == nil {
-			return types.MapCommandResponse{}, errors.New("There was an issue with the API response")
-		}
-		body, _ := io.ReadAll(response.Body)
-		if response.StatusCode > 299 {
-			log.Fatalf("Response failed with status code: %d and\nbody: %s\n", response.StatusCode, body)
-		}
-		responseBytes := []byte(body)
-		error := Unmarshall(responseBytes, &locations)
-		if error != nil {
-			log.Fatalf("Failed to unmarshal response: %s\n", error)
-		}
-		config.NEXT_URL = &locations.Next
-		config.PREV_URL = &locations.Previous
-
-		return types.MapCommandResponse{Locations: locations.Results}, nil
-	} else {
-		fmt.Println("Cache Hit")
-
-		marshalingError := Unmarshall(cachedBytes, &locations)
-		if marshalingError != nil {
-			log.Fatalf("Failed to unmarshal response: %s\n", marshalingError)
-		}
-		return types.MapCommandResponse{Locations: locations.Results}, nil
+	locations, err := config.Client.ListLocations(ctx, *config.PREV_URL)
+	if err != nil {
+		return types.MapCommandResponse{}, err
 	}
-
+	config.NEXT_URL = &locations.Next
+	config.PREV_URL = &locations.Previous
+	return types.MapCommandResponse{Locations: locations.Results}, nil
 }
 
-func Explore(config *types.Config, dependency types.Dependency, commandInput string) (types.CallbackResponse, error) {
+func Explore(ctx context.Context, config *types.Config, dependency types.Dependency, commandInput string) (types.CallbackResponse, error) {
 	if commandInput == "" {
 		return types.ExploreCommandResponse{}, errors.New("Please put in a location to explore")
 	}
-	url := fmt.Sprintf("https://pokeapi.co/api/v2/location-area/%s", commandInput)
-	cachedBytes, exists := config.Client.Cache.Get(url)
-	if !exists {
-		response, err := config.Client.HttpClient.Get(url)
-		if err != nil {
-			return types.ExploreCommandResponse{}, errors.New("There was an issue retrieving the data:" + err.Error())
-		}
-		body, _ := io.ReadAll(response.Body)
-		if response.StatusCode > 299 {
-			if response.StatusCode == 404 {
-				return types.ExploreCommandResponse{}, errors.New("Area was not found")
-			}
-			return types.ExploreCommandResponse{}, errors.New("There was an issue retrieving the data")
-		}
-		responseBytes := []byte(body)
-
-		var encounter types.PokemonEncountersResponse
-		unMarshallError := Unmarshall[types.PokemonEncountersResponse](responseBytes, &encounter)
-		config.Client.Cache.Add(url, responseBytes)
-		if unMarshallError != nil {
-			log.Fatalf("Failed to unmarshal response: %s\n", unMarshallError)
-			return types.ExploreCommandResponse{}, errors.New("There was an issue unmarshalling the data" + unMarshallError.Error())
-		}
-		return types.ExploreCommandResponse{Encounters: encounter.PokemonEncounters}, nil
-	} else {
-		fmt.Println("Cache hit")
-		var encounter types.PokemonEncountersResponse
-		unMarshallError := Unmarshall[types.PokemonEncountersResponse](cachedBytes, &encounter)
-		if unMarshallError != nil {
-			log.Fatalf("Failed to unmarshal response: %s\n", unMarshallError)
-			return types.ExploreCommandResponse{}, errors.New("There was an issue unmarshalling the data" + unMarshallError.Error())
-		}
-		return types.ExploreCommandResponse{Encounters: encounter.PokemonEncounters}, nil
+	area, err := config.Client.GetLocationArea(ctx, commandInput)
+	var notFound *pokeapiclient.NotFoundError
+	if errors.As(err, &notFound) {
+		return types.ExploreCommandResponse{}, fmt.Errorf("Area %s was not found: %w", commandInput, err)
 	}
-
+	if err != nil {
+		return types.ExploreCommandResponse{}, err
+	}
+	config.CurrentLocation = area.Name
+	now := time.Now().UTC()
+	for _, encounter := range area.PokemonEncounters {
+		config.Pokedex.See(pokeapiclient.ResourceID(encounter.Pokemon.URL), encounter.Pokemon.Name, area.Name, now)
+	}
+	return types.ExploreCommandResponse{Encounters: area.PokemonEncounters}, nil
 }
 
-func Catch(config *types.Config, dependency types.Dependency, commandInput string) (types.CallbackResponse, error) {
+func Catch(ctx context.Context, config *types.Config, dependency types.Dependency, commandInput string) (types.CallbackResponse, error) {
 	if commandInput == "" {
 		return types.ExploreCommandResponse{}, errors.New("Please enter a pokemon you'd like to catch")
 	}
-	url := fmt.Sprintf("https://pokeapi.co/api/v2/pokemon/%s", commandInput)
-	cachedBytes, exists := config.Client.Cache.Get(url)
-	var pokemonInformation types.PokemonInformation
-	if !exists {
-		response, err := config.Client.HttpClient.Get(url)
-		if err != nil {
-			return types.ExploreCommandResponse{}, errors.New("There was an issue retrieving the data:" + err.Error())
-		}
-		body, _ := io.ReadAll(response.Body)
-		if response.StatusCode > 299 {
-			if response.StatusCode == 404 {
-				return types.ExploreCommandResponse{}, errors.New("Pokemon was not found")
-			}
-			return types.ExploreCommandResponse{}, errors.New("There was an issue retrieving the data")
+	pokemonInformation, err := config.Client.GetPokemon(ctx, commandInput)
+	var notFound *pokeapiclient.NotFoundError
+	if errors.As(err, &notFound) {
+		return types.ExploreCommandResponse{}, fmt.Errorf("Pokemon %s was not found: %w", commandInput, err)
+	}
+	if err != nil {
+		return types.ExploreCommandResponse{}, err
+	}
+	// Harder pokemon give more experience; some forms have none listed,
+	// so those are as hard to catch as an average pokemon.
+	baseExperience := pokemonInformation.BaseExperience
+	if baseExperience <= 0 {
+		baseExperience = unknownBaseExperience
+	}
+	randNum := dependency.RandInt(baseExperience)
+	chance := float64(randNum) / float64(baseExperience)
+	if chance > 0.5 {
+		pokemonInformation.Caught = true
+		caught := types.NewCaughtPokemon(pokemonInformation)
+		caught.CaughtAt = time.Now().UTC()
+		caught.CaughtLocation = config.CurrentLocation
+		caught.Level = catchLevel(ctx, config, dependency, pokemonInformation.Name)
+		caught.IVs = make(map[string]int, len(caught.Stats))
+		for _, stat := range caught.Stats {
+			caught.IVs[stat.Name] = dependency.RandInt(types.MaxIV + 1)
 		}
-		responseBytes := []byte(body)
+		caught = config.Pokedex.AddPokemon(caught)
+		return types.PokemonInformationResponse{Information: pokemonInformation, Caught: &caught}, nil
+	}
+	pokemonInformation.Caught = false
+	config.Pokedex.See(pokemonInformation.ID, pokemonInformation.Name, config.CurrentLocation, time.Now().UTC())
+	return types.PokemonInformationResponse{Information: pokemonInformation}, nil
+}
 
-		unMarshallError := Unmarshall[types.PokemonInformation](responseBytes, &pokemonInformation)
-		if unMarshallError != nil {
-			log.Fatalf("Failed to unmarshal response: %s\n", unMarshallError)
-			return types.ExploreCommandResponse{}, errors.New("There was an issue unmarshalling the data" + unMarshallError.Error())
-		}
-		config.Client.Cache.Add(url, responseBytes)
+// unknownBaseExperience stands in for pokemon whose base experience PokeAPI
+// leaves empty.
+const unknownBaseExperience = 100
 
-	} else {
-		fmt.Println("Cache hit")
-		unMarshallError := Unmarshall[types.PokemonInformation](cachedBytes, &pokemonInformation)
-		if unMarshallError != nil {
-			log.Fatalf("Failed to unmarshal response: %s\n", unMarshallError)
-			return types.ExploreCommandResponse{}, errors.New("There was an issue unmarshalling the data" + unMarshallError.Error())
-		}
+// defaultCatchLevel is used when the current area does not say what level
+// a pokemon is found at.
+const defaultCatchLevel = 5
 
+// catchLevel picks a level for a new catch within the range the pokemon is
+// encountered at in the current area. The area is normally still cached
+// from exploring it.
+func catchLevel(ctx context.Context, config *types.Config, dependency types.Dependency, name string) int {
+	if config.CurrentLocation == "" {
+		return defaultCatchLevel
 	}
-	randNum := dependency.RandInt(pokemonInformation.BaseExperience)
-	chance := float64(randNum) / float64(pokemonInformation.BaseExperience)
-	if chance > 0.5 {
-		pokemonInformation.Caught = true
-		config.Pokedex.AddPokemon(pokemonInformation)
-	} else {
-		pokemonInformation.Caught = false
+	area, err := config.Client.GetLocationArea(ctx, config.CurrentLocation)
+	if err != nil {
+		return defaultCatchLevel
 	}
-
-	return types.PokemonInformationResponse{Information: pokemonInformation}, nil
+	low, high := 0, 0
+	for _, encounter := range area.PokemonEncounters {
+		if encounter.Pokemon.Name != name {
+			continue
+		}
+		for _, version := range encounter.VersionDetails {
+			for _, detail := range version.EncounterDetails {
+				if low == 0 || detail.MinLevel < low {
+					low = detail.MinLevel
+				}
+				if detail.MaxLevel > high {
+					high = detail.MaxLevel
+				}
+			}
+		}
+	}
+	if low <= 0 || high < low {
+		return defaultCatchLevel
+	}
+	return low + dependency.RandInt(high-low+1)
 }
 
-func Inspect(config *types.Config, dependency types.Dependency, commandInput string) (types.CallbackResponse, error) {
-	if commandInput == "" {
+func Inspect(ctx context.Context, config *types.Config, dependency types.Dependency, commandInput string) (types.CallbackResponse, error) {
+	name, option, _ := strings.Cut(commandInput, " ")
+	if name == "" {
 		return types.InspectCommandResponse{}, errors.New("Please enter a pokemon you'd like to inspect")
 	}
-	pokemon, err := config.Pokedex.GetPokemon(commandInput)
+	if option != "" && option != "full" {
+		return types.InspectCommandResponse{}, fmt.Errorf("Unknown inspect option %q: use inspect <pokemon> [full]", option)
+	}
+	pokemon, err := config.Pokedex.GetPokemon(name)
 	if err != nil {
 		return types.InspectCommandResponse{}, err
 	}
-	return types.InspectCommandResponse{Pokemon: pokemon}, nil
+	if option != "full" {
+		return types.InspectCommandResponse{Pokemon: pokemon}, nil
+	}
+	details, err := pokemon.Details(ctx, config.Client)
+	if err != nil {
+		return types.InspectCommandResponse{}, fmt.Errorf("Could not fetch the details for %s: %w", name, err)
+	}
+	return types.InspectCommandResponse{Pokemon: pokemon, Details: &details}, nil
 }
 
-func Pokedex(config *types.Config, dependency types.Dependency, commandInput string) (types.CallbackResponse, error) {
-	return types.PokedexCommandResponse{Pokedex: config.Pokedex}, nil
+func Stats(ctx context.Context, config *types.Config, dependency types.Dependency, commandInput string) (types.CallbackResponse, error) {
+	if config.Client.Limiter == nil {
+		return types.StatsCommandResponse{}, nil
+	}
+	stats := config.Client.Limiter.Stats()
+	return types.StatsCommandResponse{Limiter: &stats}, nil
 }
-func Unmarshall[T types.GetLocationsResponse | types.PokemonEncountersResponse | types.PokemonInformation](val []byte, v *T) error {
-
-	unmarshalError := json.Unmarshal(val, &v)
-	if unmarshalError != nil {
-		log.Fatalf("Failed to unmarshal response: %s\n", unmarshalError)
+func Cache(ctx context.Context, config *types.Config, dependency types.Dependency, commandInput string) (types.CallbackResponse, error) {
+	cache := config.Client.Cache
+	subcommand, key, _ := strings.Cut(commandInput, " ")
+	inspectable, canInspect := cache.(pokeapiclient.InspectableCache)
+	switch subcommand {
+	case "", "stats", "list", "clear":
+		if !canInspect {
+			return types.CacheCommandResponse{Message: "This cache does not keep any statistics"}, nil
+		}
+	}
+	switch subcommand {
+	case "", "stats":
+		stats := inspectable.Stats()
+		return types.CacheCommandResponse{Stats: &stats}, nil
+	case "list":
+		return types.CacheCommandResponse{Entries: inspectable.Entries()}, nil
+	case "clear":
+		return types.CacheCommandResponse{Message: fmt.Sprintf("Cleared %d cached responses", inspectable.Clear())}, nil
+	case "evict":
+		if key == "" {
+			return types.CacheCommandResponse{}, errors.New("Please enter the URL or API path to evict")
+		}
+		// Accept a path such as pokemon/pikachu as well as a full URL.
+		if !cache.Remove(key) && !cache.Remove(config.Client.Endpoint(key)) {
+			return types.CacheCommandResponse{}, fmt.Errorf("%s is not cached", key)
+		}
+		return types.CacheCommandResponse{Message: fmt.Sprintf("Evicted %s", key)}, nil
+	}
+	return types.CacheCommandResponse{}, fmt.Errorf("Unknown cache subcommand %q, try stats, list, clear or evict <key>", subcommand)
+}
+func Record(ctx context.Context, config *types.Config, dependency types.Dependency, commandInput string) (types.CallbackResponse, error) {
+	switch commandInput {
+	case "":
+		recorder := config.Client.Recording()
+		if recorder == nil {
+			return types.RecordCommandResponse{Message: "Not recording. Start with: record <dir>"}, nil
+		}
+		written, lastErr := recorder.Status()
+		message := fmt.Sprintf("Recording into %s, %d responses so far", recorder.Dir, written)
+		if lastErr != nil {
+			message += fmt.Sprintf(" (last error: %s)", lastErr)
+		}
+		return types.RecordCommandResponse{Message: message}, nil
+	case "stop":
+		recorder := config.Client.StopRecording()
+		if recorder == nil {
+			return types.RecordCommandResponse{}, errors.New("Not recording")
+		}
+		written, _ := recorder.Status()
+		return types.RecordCommandResponse{Message: fmt.Sprintf("Recorded %d responses into %s", written, recorder.Dir)}, nil
+	}
+	if config.Client.Offline != nil {
+		return types.RecordCommandResponse{}, errors.New("Cannot record while offline")
 	}
+	recorder := config.Client.StartRecording(commandInput)
+	return types.RecordCommandResponse{Message: fmt.Sprintf("Recording into %s", recorder.Dir)}, nil
+}
+func Save(ctx context.Context, config *types.Config, dependency types.Dependency, commandInput string) (types.CallbackResponse, error) {
+	path := commandInput
+	if path == "" {
+		path = config.SavePath
+	}
+	if path == "" {
+		return types.SaveCommandResponse{}, errors.New("Please enter a path to save to")
+	}
+	if err := types.WriteSaveFile(path, config.SaveFile()); err != nil {
+		return types.SaveCommandResponse{}, fmt.Errorf("Could not save the Pokedex: %w", err)
+	}
+	return types.SaveCommandResponse{Path: path, Count: config.Pokedex.Len()}, nil
+}
 
-	return nil
+func Load(ctx context.Context, config *types.Config, dependency types.Dependency, commandInput string) (types.CallbackResponse, error) {
+	path := commandInput
+	if path == "" {
+		path = config.SavePath
+	}
+	if path == "" {
+		return types.LoadCommandResponse{}, errors.New("Please enter a path to load from")
+	}
+	if _, err := os.Stat(path); err != nil {
+		return types.LoadCommandResponse{}, fmt.Errorf("Could not load the Pokedex: %w", err)
+	}
+	save, err := types.ReadSaveFile(path)
+	if err != nil {
+		return types.LoadCommandResponse{}, fmt.Errorf("Could not load the Pokedex: %w", err)
+	}
+	config.ApplySaveFile(save)
+	return types.LoadCommandResponse{Path: path, Count: save.Pokedex.Len()}, nil
 }
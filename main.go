@@ -1,9 +1,74 @@
 package main
 
 import (
+	"flag"
+	"strings"
+	"time"
+
+	"github.com/mdwiltfong/PokeDex/internal/pokeapiclient"
+	"github.com/mdwiltfong/PokeDex/internal/pokecache"
+	"github.com/mdwiltfong/PokeDex/internal/types"
 	"github.com/mdwiltfong/PokeDex/internal/utils"
 )
 
 func main() {
-	utils.StartRepl()
+	baseURL := flag.String("base-url", pokeapiclient.BaseURLFromEnv(), "PokeAPI base URL (overrides $"+pokeapiclient.BaseURLEnv+")")
+	rate := flag.Float64("rate", pokeapiclient.DefaultRequestsPerSecond, "maximum PokeAPI requests per second (0 disables the limit)")
+	burst := flag.Int("burst", pokeapiclient.DefaultBurst, "number of requests allowed back to back before -rate applies")
+	cacheTTL := flag.Duration("cache-ttl", 5*time.Second, "how often expired API responses are swept from the cache")
+	locationTTL := flag.Duration("location-ttl", pokeapiclient.DefaultCacheTTLs.Locations, "how long location responses stay cached")
+	pokemonTTL := flag.Duration("pokemon-ttl", pokeapiclient.DefaultCacheTTLs.Pokemon, "how long pokemon responses stay cached")
+	staleFor := flag.Duration("stale-for", pokeapiclient.DefaultStaleFor, "how long expired responses may be served while they are refreshed (0 disables)")
+	cacheMaxEntries := flag.Int("cache-max-entries", 0, "maximum number of cached API responses (0 means no limit)")
+	cacheMaxBytes := flag.Int("cache-max-bytes", 64<<20, "maximum total size of cached API responses in bytes (0 means no limit)")
+	cacheBackend := flag.String("cache", "memory", "where to cache API responses: memory, disk (kept between sessions) or none")
+	cacheDir := flag.String("cache-dir", defaultCacheDir(), "directory for -cache disk")
+	offline := flag.String("offline", "", "answer every request from the snapshot in this directory instead of the network")
+	record := flag.String("record", "", "record every API response into a snapshot in this directory")
+	profilesDir := flag.String("profiles-dir", defaultProfilesDir(), "directory of trainer profiles, loaded on startup and saved on exit (empty disables)")
+	profile := flag.String("profile", types.DefaultProfile, "trainer profile to play as")
+	flag.Parse()
+
+	utils.StartRepl(utils.ReplOptions{
+		BaseURL:           *baseURL,
+		RequestsPerSecond: *rate,
+		Burst:             *burst,
+		CacheTTL:          *cacheTTL,
+		LocationTTL:       *locationTTL,
+		PokemonTTL:        *pokemonTTL,
+		StaleFor:          *staleFor,
+		CacheMaxEntries:   *cacheMaxEntries,
+		CacheMaxBytes:     *cacheMaxBytes,
+		CacheBackend:      *cacheBackend,
+		CacheDir:          *cacheDir,
+		OfflineDir:        *offline,
+		RecordDir:         *record,
+		ProfilesDir:       *profilesDir,
+		Profile:           strings.ToLower(*profile),
+		LegacySavePath:    legacySavePath(),
+	})
+}
+
+func defaultCacheDir() string {
+	dir, err := pokecache.DefaultDir()
+	if err != nil {
+		return ".pokedex-cache"
+	}
+	return dir
+}
+
+func legacySavePath() string {
+	path, err := types.LegacySavePath()
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
+func defaultProfilesDir() string {
+	dir, err := types.DefaultProfilesDir()
+	if err != nil {
+		return "profiles"
+	}
+	return dir
 }